@@ -0,0 +1,234 @@
+// Command firefly-player plays a project on LED strips without the editor, for example on a permanently installed
+// Raspberry Pi. The outputs are described by a JSON file with the same format as the outputs in the settings of
+// the editor:
+//
+//	{
+//		"frameRate": 60,
+//		"outputs": [
+//			{"name": "Facade", "enabled": true, "protocol": "wled", "address": "192.168.1.20", "motionBlurSamples": 4, "mapping": {...}}
+//		]
+//	}
+//
+// By default the project is played once from the beginning. It can start at an offset, repeat forever, start at a
+// scheduled time or follow the wall clock so that multiple players show the same frame without talking to each other:
+//
+//	firefly-player -config outputs.json [-offset 0] [-loop] [-start 20:00 | -start 2021-12-31T23:59:00+01:00] [-wallclock] show.ffp
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/storage"
+	"github.com/omniskop/firefly/pkg/streamer"
+	"github.com/sirupsen/logrus"
+)
+
+// syncInterval is the time after which the pipeline is synchronized with the wall clock again
+const syncInterval = time.Second
+
+// playerConfig is the content of the config file
+type playerConfig struct {
+	FrameRate float64                 `json:"frameRate"` // 0 uses the streamer.DefaultFrameRate
+	Outputs   []streamer.OutputConfig `json:"outputs"`
+}
+
+func main() {
+	configPath := flag.String("config", "", "a JSON file with the frame rate and outputs")
+	offset := flag.Float64("offset", 0, "the time in the project in seconds at which playback starts")
+	loop := flag.Bool("loop", false, "repeat the project until the player is stopped")
+	start := flag.String("start", "", "start at a time of day like 20:00 which repeats every day, or at a date in RFC 3339 format")
+	wallclock := flag.Bool("wallclock", false, "derive the position from the wall clock so that multiple players stay in sync, implies -loop")
+	flag.Parse()
+	if *configPath == "" || flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: firefly-player -config outputs.json [flags] project.ffp")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	proj, err := storage.LoadFile(flag.Arg(0))
+	if err != nil {
+		logrus.Fatalf("project could not be loaded: %v", err)
+	}
+	if proj.Duration <= 0 {
+		logrus.Fatal("the project has no duration")
+	}
+	if *offset < 0 || *offset >= proj.Duration {
+		logrus.Fatalf("the offset needs to be between 0 and the duration of the project (%.2f seconds)", proj.Duration)
+	}
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	var sched *schedule
+	if *start != "" {
+		sched, err = parseSchedule(*start)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
+	pipeline := newPipeline(proj, config)
+	defer pipeline.Stop()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	p := player{
+		pipeline:  pipeline,
+		duration:  proj.Duration,
+		offset:    *offset,
+		loop:      *loop || *wallclock,
+		wallclock: *wallclock,
+		interrupt: interrupt,
+	}
+	for {
+		begin := time.Now()
+		if sched != nil {
+			begin = sched.next(time.Now(), p.length())
+			if sched.once && !p.loop && time.Since(begin).Seconds() >= p.length() {
+				logrus.Info("the scheduled show is already over")
+				return
+			}
+			logrus.Infof("next show starts at %s", begin.Format(time.RFC1123))
+			if !p.wait(begin) {
+				return
+			}
+		}
+		logrus.Infof("playing %q", proj.Title)
+		if !p.play(begin) {
+			return
+		}
+		logrus.Info("the show is over")
+		if sched == nil || sched.once {
+			return
+		}
+	}
+}
+
+func loadConfig(path string) (playerConfig, error) {
+	var config playerConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("invalid config: %w", err)
+	}
+	if len(config.Outputs) == 0 {
+		return config, fmt.Errorf("the config contains no outputs")
+	}
+	if config.FrameRate <= 0 {
+		config.FrameRate = streamer.DefaultFrameRate
+	}
+	return config, nil
+}
+
+// newPipeline creates a paused pipeline with the outputs of the config.
+// Outputs that can't be created are skipped and outputs that can't connect try again while playing.
+func newPipeline(proj *project.Project, config playerConfig) *streamer.Pipeline {
+	var outputs []*streamer.Output
+	for _, outputConfig := range config.Outputs {
+		output, err := streamer.NewOutput(&proj.Scene, outputConfig)
+		if err != nil {
+			logrus.Errorf("output %q: %v", outputConfig.Name, err)
+			continue
+		}
+		// the samples are spread across the time between two frames
+		output.Scanner.SetMotionBlur(outputConfig.MotionBlurSamples, 1/config.FrameRate)
+		err = output.Connect()
+		if err != nil {
+			logrus.Errorf("output %q: %v", outputConfig.Name, err)
+		}
+		outputs = append(outputs, output)
+	}
+	pipeline := streamer.NewPipeline(outputs...)
+	pipeline.SetFrameRate(config.FrameRate)
+	pipeline.Sync(0, 0)
+	return pipeline
+}
+
+// player synchronizes the pipeline with the wall clock
+type player struct {
+	pipeline  *streamer.Pipeline
+	duration  float64 // the duration of the project in seconds
+	offset    float64
+	loop      bool
+	wallclock bool
+	interrupt <-chan os.Signal
+}
+
+// length returns the number of seconds that a single pass through the project takes
+func (p player) length() float64 {
+	return p.duration - p.offset
+}
+
+// position returns the time in the project at the moment and false if the show has ended
+func (p player) position(begin, now time.Time) (float64, bool) {
+	if p.wallclock {
+		// the time since the unix epoch is the same on all players with a synchronized clock
+		duration := time.Duration(p.duration * float64(time.Second))
+		elapsed := time.Duration(now.UnixNano()) + time.Duration(p.offset*float64(time.Second))
+		return (elapsed % duration).Seconds(), true
+	}
+	elapsed := now.Sub(begin).Seconds()
+	if elapsed < p.length() {
+		return p.offset + elapsed, true
+	}
+	if !p.loop {
+		return p.duration, false
+	}
+	// every following pass starts at the beginning of the project
+	elapsed -= p.length()
+	for elapsed >= p.duration {
+		elapsed -= p.duration
+	}
+	return elapsed, true
+}
+
+// play plays the project from the moment of begin until it ends.
+// It returns false if the player has been interrupted.
+func (p player) play(begin time.Time) bool {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.interrupt:
+			return false
+		case <-timer.C:
+		}
+		t, playing := p.position(begin, time.Now())
+		if !playing {
+			// keep showing the last frame
+			p.pipeline.Sync(p.duration, 0)
+			return true
+		}
+		p.pipeline.Sync(t, 1)
+
+		// synchronize again at the end of the project to start the next pass exactly on time
+		wait := syncInterval
+		if remaining := time.Duration((p.duration - t) * float64(time.Second)); remaining < wait {
+			wait = remaining
+		}
+		timer.Reset(wait)
+	}
+}
+
+// wait blocks until the moment and returns false if the player has been interrupted
+func (p player) wait(moment time.Time) bool {
+	timer := time.NewTimer(time.Until(moment))
+	defer timer.Stop()
+	select {
+	case <-p.interrupt:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// schedule is the time at which the show starts
+type schedule struct {
+	once      bool      // the schedule is a single date instead of a time of day
+	date      time.Time // the date of a single show
+	timeOfDay time.Duration
+}
+
+// parseSchedule parses a time of day like "20:00" or "20:00:30" or a date in RFC 3339 format
+func parseSchedule(value string) (*schedule, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return &schedule{timeOfDay: time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second}, nil
+		}
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time %q, expected a time of day like 20:00 or a date like 2006-01-02T15:04:05+01:00", value)
+	}
+	return &schedule{once: true, date: date}, nil
+}
+
+// next returns the start of the show that is due after now. A show that has started less than length seconds ago
+// is still returned so that a player that has been restarted joins the running show.
+func (s *schedule) next(now time.Time, length float64) time.Time {
+	if s.once {
+		return s.date
+	}
+	year, month, day := now.Date()
+	for _, offset := range []int{-1, 0, 1} {
+		// the time of day is added to midnight of the day to handle changes of the daylight saving time
+		start := time.Date(year, month, day+offset, 0, 0, 0, 0, now.Location()).Add(s.timeOfDay)
+		if now.Sub(start).Seconds() < length {
+			return start
+		}
+	}
+	return time.Date(year, month, day+2, 0, 0, 0, 0, now.Location()).Add(s.timeOfDay)
+}
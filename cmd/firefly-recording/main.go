@@ -0,0 +1,163 @@
+// Command firefly-recording replays and compares recordings of the frames that Firefly sent to an output.
+//
+//	firefly-recording replay [-output config.json] [-protocol wled] [-address host] [-port port] [-loop] recording.ffr
+//	firefly-recording diff a.ffr b.ffr
+//
+// The output config uses the same format as an output in the settings of the editor.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/omniskop/firefly/pkg/streamer"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "replay":
+		err = replay(os.Args[2:])
+	case "diff":
+		var equal bool
+		equal, err = diff(os.Args[2:])
+		if err == nil && !equal {
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+	if err != nil {
+		logrus.Error(err)
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: firefly-recording replay [flags] recording.ffr")
+	fmt.Fprintln(os.Stderr, "       firefly-recording diff a.ffr b.ffr")
+	os.Exit(2)
+}
+
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	outputPath := flags.String("output", "", "a JSON file with the configuration of the output")
+	protocol := flags.String("protocol", string(streamer.ProtocolWLED), "the protocol that is used to send the frames")
+	address := flags.String("address", "127.0.0.1", "the address of the device")
+	port := flags.Int("port", 0, "the port of the device, 0 uses the default port of the protocol")
+	device := flags.String("device", "", "the serial device for serial protocols")
+	loop := flags.Bool("loop", false, "replay the recording until the command is interrupted")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	config := streamer.DefaultOutputConfig()
+	if *outputPath != "" {
+		data, err := os.ReadFile(*outputPath)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(data, &config)
+		if err != nil {
+			return fmt.Errorf("invalid output config: %w", err)
+		}
+	}
+	// flags that have been set explicitly override the config file
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "protocol":
+			config.Protocol = streamer.Protocol(*protocol)
+		case "address":
+			config.Address = *address
+		case "port":
+			config.Port = *port
+		case "device":
+			config.SerialDevice = *device
+		}
+	})
+
+	str, err := config.NewStreamer()
+	if err != nil {
+		return err
+	}
+	writer, closer, err := config.OpenDestination()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	str.SetDestination(writer)
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	for {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		err = streamer.Replay(file, str, stop)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if !*loop {
+			return nil
+		}
+	}
+}
+
+// diff prints the differences between two recordings and returns true if they are equal
+func diff(args []string) (bool, error) {
+	if len(args) != 2 {
+		usage()
+	}
+	a, err := os.Open(args[0])
+	if err != nil {
+		return false, err
+	}
+	defer a.Close()
+	b, err := os.Open(args[1])
+	if err != nil {
+		return false, err
+	}
+	defer b.Close()
+
+	result, err := streamer.DiffRecordings(a, b)
+	if err != nil {
+		return false, err
+	}
+	if result.Equal() {
+		fmt.Printf("the recordings are equal (%d frames)\n", result.Frames)
+		return true, nil
+	}
+	fmt.Printf("compared frames: %d\n", result.Frames)
+	if result.ExtraFrames > 0 {
+		fmt.Printf("additional frames in the longer recording: %d\n", result.ExtraFrames)
+	}
+	if result.ChangedFrames > 0 {
+		fmt.Printf("changed frames: %d\n", result.ChangedFrames)
+		fmt.Printf("changed pixels: %d\n", result.ChangedPixels)
+		fmt.Printf("largest channel difference: %d of 65535\n", result.MaxDifference)
+		fmt.Printf("first change: frame %d at %.3f seconds\n", result.FirstChange, result.FirstTime)
+	}
+	return false, nil
+}
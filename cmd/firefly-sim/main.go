@@ -0,0 +1,132 @@
+// Command firefly-sim simulates an LED strip in the terminal. It receives the frames of a streamer over UDP and
+// shows the pixels as true color blocks together with the frame rate at which they arrive.
+//
+//	firefly-sim [-protocol wled] [-listen :21324] [-order RGB] [-gamma 0] [-width 50]
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/color"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/omniskop/firefly/pkg/decoder"
+	"github.com/omniskop/firefly/pkg/streamer"
+	"github.com/sirupsen/logrus"
+)
+
+// strip is the state of the simulated strip that is shared between the receiver and the display
+type strip struct {
+	pixels    []color.RGBA64
+	frames    int // the number of frames that have been received
+	errors    int // the number of packets that couldn't be decoded
+	lastError error
+	mutex     sync.Mutex
+}
+
+func main() {
+	protocol := flag.String("protocol", string(streamer.ProtocolWLED), "the protocol of the streamer: wled, basic or gob")
+	listen := flag.String("listen", "", "the address to listen on, defaults to the port of the protocol")
+	order := flag.String("order", "RGB", "the channel order of the streamer like RGB or GRBW")
+	gamma := flag.Float64("gamma", 0, "the gamma that the streamer applied, 0 uses the default of the protocol")
+	width := flag.Int("width", 50, "the number of pixels per line")
+	refresh := flag.Float64("refresh", 30, "the number of times per second the terminal is redrawn")
+	flag.Parse()
+
+	dec, err := decoder.New(streamer.Protocol(*protocol), decoder.Layout{ChannelOrder: *order, Gamma: *gamma})
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if *width < 1 || *refresh <= 0 {
+		logrus.Fatal("the width and refresh rate need to be positive")
+	}
+	address := *listen
+	if address == "" {
+		address = ":" + strconv.Itoa(streamer.Protocol(*protocol).DefaultPort())
+	}
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer conn.Close()
+
+	state := &strip{}
+	go receive(conn, dec, state)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	// clear the screen and hide the cursor
+	fmt.Print("\x1b[2J\x1b[?25l")
+	defer fmt.Print("\x1b[0m\x1b[?25h\n")
+
+	title := fmt.Sprintf("%s on %s", *protocol, conn.LocalAddr())
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / *refresh))
+	defer ticker.Stop()
+	var output bytes.Buffer
+	lastCount := time.Now()
+	lastFrames := 0
+	var rate float64
+	for {
+		select {
+		case <-interrupt:
+			return
+		case now := <-ticker.C:
+			state.mutex.Lock()
+			if elapsed := now.Sub(lastCount); elapsed >= time.Second {
+				rate = float64(state.frames-lastFrames) / elapsed.Seconds()
+				lastFrames = state.frames
+				lastCount = now
+			}
+			output.Reset()
+			draw(&output, state, *width)
+			fmt.Fprintf(&output, "\x1b[0m%s | %d pixels | %.1f fps | %d frames | %d errors\x1b[K\n",
+				title, len(state.pixels), rate, state.frames, state.errors)
+			if state.lastError != nil {
+				fmt.Fprintf(&output, "last error: %v\x1b[K\n", state.lastError)
+			}
+			// clear the rest of the screen in case the strip got shorter
+			output.WriteString("\x1b[J")
+			state.mutex.Unlock()
+			os.Stdout.Write(output.Bytes())
+		}
+	}
+}
+
+// receive decodes the packets of the connection until it is closed
+func receive(conn net.PacketConn, dec decoder.Decoder, state *strip) {
+	packet := make([]byte, 0x10000)
+	for {
+		n, _, err := conn.ReadFrom(packet)
+		if err != nil {
+			return
+		}
+		frame, complete, err := dec.Decode(packet[:n])
+		state.mutex.Lock()
+		if err != nil {
+			state.errors++
+			state.lastError = err
+		} else if complete {
+			state.pixels = append(state.pixels[:0], frame.Pixels...)
+			state.frames++
+		}
+		state.mutex.Unlock()
+	}
+}
+
+// draw writes the pixels of the strip as rows of colored blocks, starting in the top left corner
+func draw(output *bytes.Buffer, state *strip, width int) {
+	output.WriteString("\x1b[H")
+	for i, pixel := range state.pixels {
+		// two characters per pixel look roughly square in most terminals
+		fmt.Fprintf(output, "\x1b[38;2;%d;%d;%dm██", pixel.R>>8, pixel.G>>8, pixel.B>>8)
+		if (i+1)%width == 0 || i == len(state.pixels)-1 {
+			output.WriteString("\x1b[0m\x1b[K\n")
+		}
+	}
+}
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"math"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/omniskop/firefly/pkg/scanner"
+	"github.com/omniskop/firefly/pkg/streamer"
 
 	"github.com/therecipe/qt/quick"
 
 	"github.com/omniskop/firefly/cmd/firefly/settings"
+	"github.com/sirupsen/logrus"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/gui"
 )
@@ -147,6 +150,55 @@ func (m *mappingModel) setScannerMapping(mapping *scanner.Mapping) {
 
 // ====
 
+// calibrationModel exposes a streamer.Calibration to the settings view.
+// The lookup tables can't be edited in the view but are preserved.
+type calibrationModel struct {
+	core.QObject
+
+	_            string  `property:"channelOrder"`
+	_            float32 `property:"gammaRed"`
+	_            float32 `property:"gammaGreen"`
+	_            float32 `property:"gammaBlue"`
+	_            float32 `property:"gammaWhite"`
+	_            float32 `property:"whitePointRed"`
+	_            float32 `property:"whitePointGreen"`
+	_            float32 `property:"whitePointBlue"`
+	_            int     `property:"temperature"`
+	lookupTables [4][]float64
+}
+
+func (m *calibrationModel) streamerCalibration() streamer.Calibration {
+	return streamer.Calibration{
+		ChannelOrder: m.ChannelOrder(),
+		Gamma:        [4]float64{float64(m.GammaRed()), float64(m.GammaGreen()), float64(m.GammaBlue()), float64(m.GammaWhite())},
+		LookupTables: m.lookupTables,
+		WhitePoint:   [3]float64{float64(m.WhitePointRed()), float64(m.WhitePointGreen()), float64(m.WhitePointBlue())},
+		Temperature:  float64(m.Temperature()),
+	}
+}
+
+func (m *calibrationModel) setStreamerCalibration(calibration streamer.Calibration) {
+	m.SetChannelOrder(calibration.ChannelOrder)
+	m.SetGammaRed(float32(calibration.Gamma[0]))
+	m.SetGammaGreen(float32(calibration.Gamma[1]))
+	m.SetGammaBlue(float32(calibration.Gamma[2]))
+	m.SetGammaWhite(float32(calibration.Gamma[3]))
+	m.lookupTables = calibration.LookupTables
+	// a factor of 0 doesn't correct the channel, which is shown as 1
+	whitePoint := calibration.WhitePoint
+	for i, factor := range whitePoint {
+		if factor == 0 {
+			whitePoint[i] = 1
+		}
+	}
+	m.SetWhitePointRed(float32(whitePoint[0]))
+	m.SetWhitePointGreen(float32(whitePoint[1]))
+	m.SetWhitePointBlue(float32(whitePoint[2]))
+	m.SetTemperature(int(calibration.Temperature))
+}
+
+// ====
+
 type audioSource struct {
 	core.QObject
 
@@ -229,14 +281,54 @@ type appSettingsModel struct {
 
 	_ string `property:"editorPasteMode"`
 
-	_ bool   `property:"liveLedStripEnabled"`
+	_ bool `property:"liveLedStripEnabled"`
+	_ int  `property:"liveLedStripFrameRate"`
+
+	// the following properties show the output at the index currentOutput
+	outputs []streamer.OutputConfig
+	_       []string `property:"outputNames"`
+	_       int      `property:"currentOutput"`
+	_       string   `property:"outputName"`
+	_       bool     `property:"outputEnabled"`
+	_       int      `property:"outputMotionBlurSamples"`
+
+	_ string `property:"liveLedStripProtocol"`
 	_ string `property:"liveLedStripAddress"`
 	_ int    `property:"liveLedStripPort"`
+	_ string `property:"liveLedStripSerialDevice"`
+	_ int    `property:"liveLedStripBaudRate"`
+	_ int    `property:"liveLedStripTimeout"`
+	_ int    `property:"liveLedStripOpcChannel"`
+
+	// the baud rates that can be chosen for serial devices
+	_ []string `property:"serialBaudRates"`
+
+	_ int    `property:"liveLedStripE131Universe"`
+	_ int    `property:"liveLedStripE131StartChannel"`
+	_ int    `property:"liveLedStripE131Priority"`
+	_ string `property:"liveLedStripE131SourceName"`
+	_ bool   `property:"liveLedStripE131Multicast"`
+
+	_ int  `property:"liveLedStripArtNetNet"`
+	_ int  `property:"liveLedStripArtNetSubNet"`
+	_ int  `property:"liveLedStripArtNetUniverse"`
+	_ bool `property:"liveLedStripArtNetDisableSync"`
+	_ int  `property:"liveLedStripPixelsPerUniverse"`
+
+	_ float32 `property:"powerChannelCurrent"` // mA
+	_ float32 `property:"powerIdleCurrent"`    // mA
+	_ float32 `property:"powerLimit"`          // A
 
 	_ int          `property:"liveLedStripMappingMode"` // 0 = simple/linear; 1 = custom
 	_ int          `property:"ledCount"`
 	_ mappingModel `property:"mapping"`
 
+	_ calibrationModel `property:"calibration"`
+
+	_ func(int) `slot:"selectOutput,auto"`
+	_ func()    `slot:"addOutput,auto"`
+	_ func()    `slot:"removeOutput,auto"`
+
 	_ func() `constructor:"init"`
 	_ func() `slot:"ok"`
 	_ func() `slot:"cancel"`
@@ -247,23 +339,146 @@ func (m *appSettingsModel) init() {
 
 	m.SetEditorPasteMode(settings.GetString("editor/pasteMode"))
 	m.SetLiveLedStripEnabled(settings.GetBool("liveLedStrip/enabled"))
-	m.SetLiveLedStripAddress(settings.GetString("liveLedStrip/address"))
-	m.SetLiveLedStripPort(settings.GetInt("liveLedStrip/port"))
+	m.SetLiveLedStripFrameRate(settings.GetInt("liveLedStrip/frameRate"))
+	baudRates := make([]string, len(streamer.SerialBaudRates))
+	for i, rate := range streamer.SerialBaudRates {
+		baudRates[i] = strconv.Itoa(rate)
+	}
+	m.SetSerialBaudRates(baudRates)
 
-	var mapping *scanner.Mapping
-	err := json.Unmarshal([]byte(settings.GetString("liveLedStrip/mapping")), &mapping)
-	if err != nil {
-		mapping = scanner.NewLinearMapping(30)
+	err := json.Unmarshal([]byte(settings.GetString("liveLedStrip/outputs")), &m.outputs)
+	if err != nil || len(m.outputs) == 0 {
+		m.outputs = []streamer.OutputConfig{streamer.DefaultOutputConfig()}
 	}
 	m.SetMapping(NewMappingModel(m))
-	m.Mapping().setScannerMapping(mapping)
-	if mappingIsLinear(mapping) {
+	m.SetCalibration(NewCalibrationModel(m))
+	m.updateOutputNames()
+	m.loadOutput(0)
+}
+
+// loadOutput shows the output at the index in the properties of the model
+func (m *appSettingsModel) loadOutput(index int) {
+	output := m.outputs[index]
+	m.SetCurrentOutput(index)
+	m.SetOutputName(output.Name)
+	m.SetOutputEnabled(output.Enabled)
+	m.SetOutputMotionBlurSamples(output.MotionBlurSamples)
+	m.SetLiveLedStripProtocol(string(output.Protocol))
+	m.SetLiveLedStripAddress(output.Address)
+	m.SetLiveLedStripPort(output.Port)
+	m.SetLiveLedStripSerialDevice(output.SerialDevice)
+	m.SetLiveLedStripBaudRate(output.BaudRate)
+	m.SetLiveLedStripTimeout(output.Timeout)
+	m.SetLiveLedStripOpcChannel(output.OPCChannel)
+	m.SetLiveLedStripE131Universe(output.E131Universe)
+	m.SetLiveLedStripE131StartChannel(output.E131StartChannel)
+	m.SetLiveLedStripE131Priority(output.E131Priority)
+	m.SetLiveLedStripE131SourceName(output.E131SourceName)
+	m.SetLiveLedStripE131Multicast(output.E131Multicast)
+	m.SetLiveLedStripArtNetNet(output.ArtNetNet)
+	m.SetLiveLedStripArtNetSubNet(output.ArtNetSubNet)
+	m.SetLiveLedStripArtNetUniverse(output.ArtNetUniverse)
+	m.SetLiveLedStripArtNetDisableSync(output.ArtNetDisableSync)
+	m.SetLiveLedStripPixelsPerUniverse(output.PixelsPerUniverse)
+
+	mapping := output.Mapping
+	if len(mapping.Segments) == 0 {
+		mapping = *scanner.NewLinearMapping(30)
+	}
+	m.Mapping().setScannerMapping(&mapping)
+	if mappingIsLinear(&mapping) {
 		m.SetLiveLedStripMappingMode(0)
 		m.SetLedCount(mapping.Segments[0].PixelSize)
 	} else {
 		m.SetLiveLedStripMappingMode(1)
 		m.SetLedCount(0)
 	}
+
+	m.Calibration().setStreamerCalibration(output.Calibration)
+
+	m.SetPowerChannelCurrent(float32(output.Power.ChannelCurrent))
+	m.SetPowerIdleCurrent(float32(output.Power.IdleCurrent))
+	m.SetPowerLimit(float32(output.Power.Limit / 1000))
+}
+
+// storeOutput writes the properties of the model back into the current output
+func (m *appSettingsModel) storeOutput() {
+	output := &m.outputs[m.CurrentOutput()]
+	output.Name = m.OutputName()
+	output.Enabled = m.IsOutputEnabled()
+	output.MotionBlurSamples = m.OutputMotionBlurSamples()
+	output.Protocol = streamer.Protocol(m.LiveLedStripProtocol())
+	output.Address = m.LiveLedStripAddress()
+	output.Port = m.LiveLedStripPort()
+	output.SerialDevice = m.LiveLedStripSerialDevice()
+	output.BaudRate = m.LiveLedStripBaudRate()
+	output.Timeout = m.LiveLedStripTimeout()
+	output.OPCChannel = m.LiveLedStripOpcChannel()
+	output.E131Universe = m.LiveLedStripE131Universe()
+	output.E131StartChannel = m.LiveLedStripE131StartChannel()
+	output.E131Priority = m.LiveLedStripE131Priority()
+	output.E131SourceName = m.LiveLedStripE131SourceName()
+	output.E131Multicast = m.IsLiveLedStripE131Multicast()
+	output.ArtNetNet = m.LiveLedStripArtNetNet()
+	output.ArtNetSubNet = m.LiveLedStripArtNetSubNet()
+	output.ArtNetUniverse = m.LiveLedStripArtNetUniverse()
+	output.ArtNetDisableSync = m.IsLiveLedStripArtNetDisableSync()
+	output.PixelsPerUniverse = m.LiveLedStripPixelsPerUniverse()
+	if m.LiveLedStripMappingMode() == 0 {
+		output.Mapping = *scanner.NewLinearMapping(m.LedCount())
+	} else {
+		output.Mapping = *m.Mapping().scannerMapping()
+	}
+	calibration := m.Calibration().streamerCalibration()
+	if err := calibration.Validate(); err != nil {
+		logrus.Errorf("calibration of output %q has not been saved: %v", output.Name, err)
+	} else {
+		output.Calibration = calibration
+	}
+	// the gamma of the power model can't be edited but is preserved
+	output.Power.ChannelCurrent = float64(m.PowerChannelCurrent())
+	output.Power.IdleCurrent = float64(m.PowerIdleCurrent())
+	output.Power.Limit = float64(m.PowerLimit()) * 1000
+	m.updateOutputNames()
+}
+
+func (m *appSettingsModel) updateOutputNames() {
+	names := make([]string, len(m.outputs))
+	for i, output := range m.outputs {
+		names[i] = output.Name
+	}
+	m.SetOutputNames(names)
+}
+
+func (m *appSettingsModel) selectOutput(index int) {
+	if index < 0 || index >= len(m.outputs) {
+		return
+	}
+	m.storeOutput()
+	m.loadOutput(index)
+}
+
+func (m *appSettingsModel) addOutput() {
+	m.storeOutput()
+	output := streamer.DefaultOutputConfig()
+	output.Name = fmt.Sprintf("LED Strip %d", len(m.outputs)+1)
+	m.outputs = append(m.outputs, output)
+	m.updateOutputNames()
+	m.loadOutput(len(m.outputs) - 1)
+}
+
+func (m *appSettingsModel) removeOutput() {
+	// at least one output is kept so that the view always has an output to show
+	if len(m.outputs) <= 1 {
+		return
+	}
+	index := m.CurrentOutput()
+	m.outputs = append(m.outputs[:index], m.outputs[index+1:]...)
+	m.updateOutputNames()
+	if index >= len(m.outputs) {
+		index = len(m.outputs) - 1
+	}
+	m.loadOutput(index)
 }
 
 func mappingIsLinear(m *scanner.Mapping) bool {
@@ -277,15 +492,10 @@ func (m *appSettingsModel) save() {
 
 	settings.Set("editor/pasteMode", m.EditorPasteMode())
 	settings.Set("liveLedStrip/enabled", m.IsLiveLedStripEnabled())
-	settings.Set("liveLedStrip/address", m.LiveLedStripAddress())
-	settings.Set("liveLedStrip/port", m.LiveLedStripPort())
-	if m.LiveLedStripMappingMode() == 0 {
-		data, _ := json.Marshal(scanner.NewLinearMapping(m.LedCount()))
-		settings.Set("liveLedStrip/mapping", string(data))
-	} else {
-		data, _ := json.Marshal(m.Mapping().scannerMapping())
-		settings.Set("liveLedStrip/mapping", string(data))
-	}
+	settings.Set("liveLedStrip/frameRate", m.LiveLedStripFrameRate())
+	m.storeOutput()
+	data, _ := json.Marshal(m.outputs)
+	settings.Set("liveLedStrip/outputs", string(data))
 }
 
 func NewAppSettingsWindow() error {
@@ -339,8 +549,7 @@ func restoreDefaultSettings() {
 	}
 	settings.Set("editor/pasteMode", "auto")
 	settings.Set("liveLedStrip/enabled", false)
-	settings.Set("liveLedStrip/address", "127.0.0.1")
-	settings.Set("liveLedStrip/port", "20202")
-	mapping, _ := json.Marshal(scanner.NewLinearMapping(30))
-	settings.Set("liveLedStrip/mapping", string(mapping))
+	settings.Set("liveLedStrip/frameRate", streamer.DefaultFrameRate)
+	outputs, _ := json.Marshal([]streamer.OutputConfig{streamer.DefaultOutputConfig()})
+	settings.Set("liveLedStrip/outputs", string(outputs))
 }
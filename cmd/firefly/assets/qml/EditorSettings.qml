@@ -58,97 +58,557 @@ ColumnLayout {
                 }
 
                 Label {
-                    id: label3
-                    text: qsTr("Address")
+                    text: qsTr("Frame Rate")
                     Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
                 }
 
                 TextField {
-                    id: textField1
-                    text: Model.liveLedStripAddress
-                    placeholderText: "192.168.178.0"
-                    validator: RegExpValidator { regExp: /\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}/ }
-                    onTextChanged: Model.liveLedStripAddress = text
+                    text: Model.liveLedStripFrameRate
+                    placeholderText: "60"
+                    validator: IntValidator {bottom: 1; top: 1000}
+                    onTextChanged: Model.liveLedStripFrameRate = text == "" ? 0 : parseInt(text)
                     Layout.fillWidth: true
                 }
 
                 Label {
-                    id: label4
-                    text: qsTr("Port")
+                    text: qsTr("Output")
                     Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
                 }
 
-                TextField {
-                    id: textField2
-                    text: Model.liveLedStripPort
-                    placeholderText: "20202"
-                    validator: IntValidator {bottom: 0; top: 65535}
-                    onTextChanged: Model.liveLedStripPort = parseInt(text)
+                RowLayout {
+                    spacing: 5
                     Layout.fillWidth: true
+
+                    ComboBox {
+                        model: Model.outputNames
+                        currentIndex: Model.currentOutput
+                        onActivated: {
+                            Model.selectOutput(index)
+                            outputLoader.reload()
+                        }
+                        Layout.fillWidth: true
+                    }
+
+                    Button {
+                        small: true; square: true
+                        text: "-"
+                        enabled: Model.outputNames.length > 1
+                        onClicked: {
+                            Model.removeOutput()
+                            outputLoader.reload()
+                        }
+                    }
+
+                    Button {
+                        small: true; square: true
+                        text: "+"
+                        onClicked: {
+                            Model.addOutput()
+                            outputLoader.reload()
+                        }
+                    }
                 }
+            } // PropertyGridLayout
 
-                Label {
-                    text: qsTr("Mapping")
-                    Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+            // the fields of the output are recreated whenever a different output is selected
+            Loader {
+                id: outputLoader
+                sourceComponent: outputComponent
+                Layout.fillWidth: true
+
+                function reload() {
+                    active = false
+                    active = true
                 }
+            }
 
-                ComboBox {
-                    id: mappingSelection
-                    model: [qsTr("Simple"), qsTr("Custom")]
-                    Layout.fillWidth: true
-                    currentIndex: Model.liveLedStripMappingMode
+            Component {
+                id: outputComponent
 
-                    Component.onCompleted: {
-                        activated(currentIndex)
+                ColumnLayout {
+                    GridLayout {
+                        columns: 2
+                        columnSpacing: 10
+
+                        Label {
+                            text: qsTr("Name")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.outputName
+                            onTextChanged: Model.outputName = text
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Output Enabled")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        CheckBox {
+                            onClicked: Model.outputEnabled = checked
+                            Component.onCompleted: checked = Model.outputEnabled
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Motion Blur Samples")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Math.max(1, Model.outputMotionBlurSamples)
+                            placeholderText: "1"
+                            validator: IntValidator {bottom: 1; top: 64}
+                            onTextChanged: Model.outputMotionBlurSamples = text == "" ? 1 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Protocol")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        ComboBox {
+                            // the values need to match the protocols of the streamer package
+                            property var protocols: ["wled", "ddp", "e131", "artnet", "basic", "opc", "adalight", "tpm2"]
+                            model: [qsTr("WLED (DRGB)"), qsTr("DDP"), qsTr("E1.31 (sACN)"), qsTr("Art-Net"), qsTr("Firefly Basic"), qsTr("Open Pixel Control"), qsTr("Adalight (Serial)"), qsTr("TPM2 (Serial)")]
+                            currentIndex: Math.max(0, protocols.indexOf(Model.liveLedStripProtocol))
+                            onActivated: Model.liveLedStripProtocol = protocols[index]
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            id: label3
+                            text: qsTr("Address")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            id: textField1
+                            text: Model.liveLedStripAddress
+                            placeholderText: "192.168.178.0"
+                            validator: RegExpValidator { regExp: /\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}/ }
+                            onTextChanged: Model.liveLedStripAddress = text
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            id: label4
+                            text: qsTr("Port")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            id: textField2
+                            text: Model.liveLedStripPort
+                            placeholderText: "20202"
+                            validator: IntValidator {bottom: 0; top: 65535}
+                            onTextChanged: Model.liveLedStripPort = parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Serial Device")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.liveLedStripSerialDevice
+                            placeholderText: "/dev/ttyUSB0"
+                            onTextChanged: Model.liveLedStripSerialDevice = text
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Baud Rate")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        ComboBox {
+                            model: Model.serialBaudRates
+                            currentIndex: Math.max(0, Model.serialBaudRates.indexOf(String(Model.liveLedStripBaudRate)))
+                            onActivated: Model.liveLedStripBaudRate = parseInt(Model.serialBaudRates[index])
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Timeout (WLED)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            // seconds after which WLED returns to its own effects, 0 keeps the last frame forever
+                            text: Model.liveLedStripTimeout
+                            placeholderText: qsTr("seconds, 0 = never")
+                            validator: IntValidator {bottom: 0; top: 254}
+                            onTextChanged: Model.liveLedStripTimeout = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Channel (OPC)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.liveLedStripOpcChannel
+                            placeholderText: qsTr("0 = all channels")
+                            validator: IntValidator {bottom: 0; top: 255}
+                            onTextChanged: Model.liveLedStripOpcChannel = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Universe (E1.31)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.liveLedStripE131Universe
+                            placeholderText: "1"
+                            validator: IntValidator {bottom: 1; top: 63999}
+                            onTextChanged: Model.liveLedStripE131Universe = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Start Channel (E1.31)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.liveLedStripE131StartChannel
+                            placeholderText: "1"
+                            validator: IntValidator {bottom: 1; top: 512}
+                            onTextChanged: Model.liveLedStripE131StartChannel = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Priority (E1.31)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.liveLedStripE131Priority
+                            placeholderText: "100"
+                            validator: IntValidator {bottom: 1; top: 200}
+                            onTextChanged: Model.liveLedStripE131Priority = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Source Name (E1.31)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.liveLedStripE131SourceName
+                            placeholderText: "Firefly"
+                            maximumLength: 63
+                            onTextChanged: Model.liveLedStripE131SourceName = text
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Multicast (E1.31)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        CheckBox {
+                            // the address is not used when the universes are sent to their multicast groups
+                            onClicked: Model.liveLedStripE131Multicast = checked
+                            Component.onCompleted: checked = Model.liveLedStripE131Multicast
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Net / Sub-Net / Universe (Art-Net)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        RowLayout {
+                            spacing: 5
+                            Layout.fillWidth: true
+
+                            TextField {
+                                text: Model.liveLedStripArtNetNet
+                                placeholderText: "0"
+                                validator: IntValidator {bottom: 0; top: 127}
+                                onTextChanged: Model.liveLedStripArtNetNet = text == "" ? 0 : parseInt(text)
+                                Layout.fillWidth: true
+                            }
+
+                            TextField {
+                                text: Model.liveLedStripArtNetSubNet
+                                placeholderText: "0"
+                                validator: IntValidator {bottom: 0; top: 15}
+                                onTextChanged: Model.liveLedStripArtNetSubNet = text == "" ? 0 : parseInt(text)
+                                Layout.fillWidth: true
+                            }
+
+                            TextField {
+                                text: Model.liveLedStripArtNetUniverse
+                                placeholderText: "0"
+                                validator: IntValidator {bottom: 0; top: 15}
+                                onTextChanged: Model.liveLedStripArtNetUniverse = text == "" ? 0 : parseInt(text)
+                                Layout.fillWidth: true
+                            }
+                        }
+
+                        Label {
+                            text: qsTr("Disable Sync (Art-Net)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        CheckBox {
+                            onClicked: Model.liveLedStripArtNetDisableSync = checked
+                            Component.onCompleted: checked = Model.liveLedStripArtNetDisableSync
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Pixels per Universe")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            // applies to E1.31 and Art-Net
+                            text: Model.liveLedStripPixelsPerUniverse
+                            placeholderText: qsTr("0 = fill universes")
+                            validator: IntValidator {bottom: 0; top: 512}
+                            onTextChanged: Model.liveLedStripPixelsPerUniverse = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Mapping")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        ComboBox {
+                            id: mappingSelection
+                            model: [qsTr("Simple"), qsTr("Custom")]
+                            Layout.fillWidth: true
+                            currentIndex: Model.liveLedStripMappingMode
+
+                            Component.onCompleted: {
+                                activated(currentIndex)
+                            }
+
+                            onActivated: {
+                                Model.liveLedStripMappingMode = index
+                                if(index == 0) {
+                                    pixelsLabel.visible = true
+                                    pixelsInput.visible = true
+                                    mappingEditorLabel.visible = false
+                                    mappingEditor.visible = false
+                                } else if(index == 1) {
+                                    pixelsLabel.visible = false
+                                    pixelsInput.visible = false
+                                    mappingEditorLabel.visible = true
+                                    mappingEditor.visible = true
+                                }
+                            }
+                        }
+
+                        Label {
+                            id: pixelsLabel
+                            text: qsTr("Pixels")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                            horizontalAlignment: Text.AlignLeft
+                        }
+
+                        TextField {
+                            id: pixelsInput
+                            text: Model.ledCount
+                            placeholderText: "60"
+                            validator: IntValidator {bottom: 1; top: 1000000}
+                            onTextChanged: Model.ledCount = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                        }
+
+                    } // PropertyGridLayout
+
+                    Label {
+                        text: qsTr("Calibration")
+                        font.weight: Font.DemiBold
+                        topPadding: 10
                     }
 
-                    onActivated: {
-                        Model.liveLedStripMappingMode = index
-                        if(index == 0) {
-                            pixelsLabel.visible = true
-                            pixelsInput.visible = true
-                            mappingEditorLabel.visible = false
-                            mappingEditor.visible = false
-                        } else if(index == 1) {
-                            pixelsLabel.visible = false
-                            pixelsInput.visible = false
-                            mappingEditorLabel.visible = true
-                            mappingEditor.visible = true
+                    GridLayout {
+                        columns: 4
+                        columnSpacing: 10
+
+                        Label {
+                            text: qsTr("Channel Order")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        ComboBox {
+                            property var orders: ["RGB", "RBG", "GRB", "GBR", "BRG", "BGR", "RGBW", "GRBW", "BRGW", "WRGB"]
+                            model: orders
+                            currentIndex: Math.max(0, orders.indexOf(Model.calibration.channelOrder))
+                            onActivated: Model.calibration.channelOrder = orders[index]
+                            Layout.fillWidth: true
+                            Layout.columnSpan: 3
+                        }
+
+                        Label {
+                            text: qsTr("Gamma R / G")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.calibration.gammaRed
+                            placeholderText: qsTr("0 = default")
+                            validator: DoubleValidator {bottom: 0; top: 10}
+                            onTextChanged: Model.calibration.gammaRed = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+
+                        TextField {
+                            text: Model.calibration.gammaGreen
+                            placeholderText: qsTr("0 = default")
+                            validator: DoubleValidator {bottom: 0; top: 10}
+                            onTextChanged: Model.calibration.gammaGreen = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                            Layout.columnSpan: 2
+                        }
+
+                        Label {
+                            text: qsTr("Gamma B / W")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.calibration.gammaBlue
+                            placeholderText: qsTr("0 = default")
+                            validator: DoubleValidator {bottom: 0; top: 10}
+                            onTextChanged: Model.calibration.gammaBlue = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+
+                        TextField {
+                            text: Model.calibration.gammaWhite
+                            placeholderText: qsTr("0 = default")
+                            validator: DoubleValidator {bottom: 0; top: 10}
+                            onTextChanged: Model.calibration.gammaWhite = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                            Layout.columnSpan: 2
+                        }
+
+                        Label {
+                            text: qsTr("White Point R / G / B")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.calibration.whitePointRed
+                            placeholderText: "1.0"
+                            validator: DoubleValidator {bottom: 0; top: 1}
+                            onTextChanged: Model.calibration.whitePointRed = text == "" ? 1 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+
+                        TextField {
+                            text: Model.calibration.whitePointGreen
+                            placeholderText: "1.0"
+                            validator: DoubleValidator {bottom: 0; top: 1}
+                            onTextChanged: Model.calibration.whitePointGreen = text == "" ? 1 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+
+                        TextField {
+                            text: Model.calibration.whitePointBlue
+                            placeholderText: "1.0"
+                            validator: DoubleValidator {bottom: 0; top: 1}
+                            onTextChanged: Model.calibration.whitePointBlue = text == "" ? 1 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Color Temperature")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.calibration.temperature
+                            placeholderText: qsTr("kelvin, 0 = off")
+                            validator: IntValidator {bottom: 0; top: 40000}
+                            onTextChanged: Model.calibration.temperature = text == "" ? 0 : parseInt(text)
+                            Layout.fillWidth: true
+                            Layout.columnSpan: 3
                         }
                     }
-                }
 
-                Label {
-                    id: pixelsLabel
-                    text: qsTr("Pixels")
-                    Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
-                    horizontalAlignment: Text.AlignLeft
-                }
+                    Label {
+                        text: qsTr("Power Limit")
+                        font.weight: Font.DemiBold
+                        topPadding: 10
+                    }
 
-                TextField {
-                    id: pixelsInput
-                    text: Model.ledCount
-                    placeholderText: "60"
-                    validator: IntValidator {bottom: 1; top: 1000000}
-                    onTextChanged: Model.ledCount = text == "" ? 0 : parseInt(text)
-                    Layout.fillWidth: true
-                }
+                    GridLayout {
+                        columns: 2
+                        columnSpacing: 10
 
-            } // PropertyGridLayout
+                        Label {
+                            text: qsTr("Current per Channel (mA)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
 
-            Label {
-                id: mappingEditorLabel
-                visible: false
-                text: "Custom Mapping"
-                font.weight: Font.DemiBold
-                topPadding: 10
-            }
+                        TextField {
+                            text: Model.powerChannelCurrent
+                            placeholderText: "20"
+                            validator: DoubleValidator {bottom: 0; top: 1000}
+                            onTextChanged: Model.powerChannelCurrent = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
 
-            MappingEditor {
-                id: mappingEditor
-                visible: false
-                Layout.fillWidth: true
-            }
+                        Label {
+                            text: qsTr("Idle Current per Pixel (mA)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.powerIdleCurrent
+                            placeholderText: "1"
+                            validator: DoubleValidator {bottom: 0; top: 1000}
+                            onTextChanged: Model.powerIdleCurrent = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+
+                        Label {
+                            text: qsTr("Power Supply Limit (A)")
+                            Layout.alignment: Qt.AlignRight | Qt.AlignVCenter
+                        }
+
+                        TextField {
+                            text: Model.powerLimit
+                            placeholderText: qsTr("0 = unlimited")
+                            validator: DoubleValidator {bottom: 0; top: 10000}
+                            onTextChanged: Model.powerLimit = text == "" ? 0 : parseFloat(text)
+                            Layout.fillWidth: true
+                        }
+                    }
+
+                    Label {
+                        id: mappingEditorLabel
+                        visible: false
+                        text: "Custom Mapping"
+                        font.weight: Font.DemiBold
+                        topPadding: 10
+                    }
+
+                    MappingEditor {
+                        id: mappingEditor
+                        visible: false
+                        Layout.fillWidth: true
+                    }
+                }
+            } // Component
         } // ColumnLayout
     } // GroupBox
 }
\ No newline at end of file
@@ -17,6 +17,9 @@ import (
 	"github.com/therecipe/qt/multimedia"
 )
 
+// notifyInterval is the time in milliseconds between two updates of the playback position
+const notifyInterval = 16
+
 type audioPlayer struct {
 	*multimedia.QMediaPlayer
 	probe          *multimedia.QAudioProbe
@@ -33,7 +36,7 @@ func NewAudioPlayer(mediapath string) *audioPlayer {
 		mediaPath:    mediapath,
 	}
 
-	player.SetNotifyInterval(16)
+	player.SetNotifyInterval(notifyInterval)
 	player.ConnectPositionChanged(player.positionChangedEvent)
 	player.ConnectMediaStatusChanged(player.mediaStatusChangedEvent)
 	player.ConnectError2(player.errorEvent)
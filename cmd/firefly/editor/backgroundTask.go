@@ -0,0 +1,68 @@
+package editor
+
+import (
+	"sync/atomic"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// backgroundTaskInterval is the time in milliseconds between two updates of the progress of a background task
+const backgroundTaskInterval = 50
+
+// progressSteps is the maximum of the progress dialog of a background task
+const progressSteps = 1000
+
+// backgroundTask is work like rendering the whole project that takes too long to run on the Qt thread.
+// Widgets may only be used on the Qt thread, so the work reports its progress here and the dialog polls it.
+type backgroundTask struct {
+	progress uint64 // the progress in steps of the dialog
+	canceled uint32 // 1 after the user canceled the task
+	done     chan struct{}
+}
+
+// report stores the fraction of the work that is done and returns false if the task has been canceled.
+// It can be passed as the progress function of long running operations.
+func (t *backgroundTask) report(done float64) bool {
+	atomic.StoreUint64(&t.progress, uint64(done*progressSteps))
+	return atomic.LoadUint32(&t.canceled) == 0
+}
+
+// runInBackground runs the work in a goroutine while a progress dialog with the label is shown.
+// After the work has returned finished is called on the Qt thread, canceled is true if the user canceled the task.
+func runInBackground(parent widgets.QWidget_ITF, label string, work func(task *backgroundTask), finished func(canceled bool)) {
+	task := &backgroundTask{done: make(chan struct{})}
+
+	dialog := widgets.NewQProgressDialog2(label, "Cancel", 0, progressSteps, parent, core.Qt__Dialog)
+	dialog.SetWindowModality(core.Qt__WindowModal)
+	dialog.SetAutoClose(false)
+	dialog.SetAutoReset(false)
+	dialog.SetMinimumDuration(500)
+	dialog.ConnectCanceled(func() {
+		atomic.StoreUint32(&task.canceled, 1)
+	})
+
+	timer := core.NewQTimer(dialog)
+	timer.ConnectTimeout(func() {
+		select {
+		case <-task.done:
+			timer.Stop()
+			canceled := atomic.LoadUint32(&task.canceled) == 1
+			dialog.Close()
+			dialog.DeleteLater()
+			finished(canceled)
+		default:
+			progress := int(atomic.LoadUint64(&task.progress))
+			if progress >= progressSteps {
+				progress = progressSteps - 1
+			}
+			dialog.SetValue(progress)
+		}
+	})
+	timer.Start(backgroundTaskInterval)
+
+	go func() {
+		defer close(task.done)
+		work(task)
+	}()
+}
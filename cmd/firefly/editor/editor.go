@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/scanner"
 	"github.com/sirupsen/logrus"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/gui"
@@ -72,6 +73,7 @@ func New(proj *project.Project, options Options, applicationCallbacks map[string
 	window.SetCentralWidget(edit.stage)
 	window.AddToolBar(core.Qt__TopToolBarArea, edit.userActions.buildToolbar())
 	window.SetMenuBar(edit.userActions.buildMenuBar())
+	window.StatusBar().AddPermanentWidget(newOutputStatus(edit.stage.needlePipeline), 0)
 
 	window.ConnectKeyPressEvent(edit.KeyPressEvent)
 	window.ConnectKeyReleaseEvent(edit.KeyReleaseEvent)
@@ -117,6 +119,44 @@ func (e *Editor) updateToolbar() {
 		return
 	}
 
+	// check the blend mode of the selected elements if they all share the same one
+	blendMode := e.stage.selection.elements[0].element.BlendMode
+	sameBlendMode := true
+	for _, item := range e.stage.selection.elements {
+		if item.element.BlendMode != blendMode {
+			sameBlendMode = false
+		}
+	}
+	if sameBlendMode {
+		e.userActions.blendModes[blendMode].SetChecked(true)
+	} else {
+		uncheckGroup(e.userActions.blendModeGroup)
+	}
+
+	// the interpolation can only be chosen if all selected elements are gradients
+	var interpolation project.ColorInterpolation
+	sameInterpolation, allGradients := true, true
+	for i, item := range e.stage.selection.elements {
+		gradient, ok := item.element.Pattern.(project.Gradient)
+		if !ok {
+			allGradients = false
+			break
+		}
+		if i == 0 {
+			interpolation = gradient.ColorInterpolation()
+		} else if gradient.ColorInterpolation() != interpolation {
+			sameInterpolation = false
+		}
+	}
+	for _, action := range e.userActions.interpolations {
+		action.SetDisabled(!allGradients)
+	}
+	if allGradients && sameInterpolation {
+		e.userActions.interpolations[interpolation].SetChecked(true)
+	} else {
+		uncheckGroup(e.userActions.interpolationGroup)
+	}
+
 	// find out if all selected elements have the same pattern type
 	var patternType string = reflect.TypeOf(e.stage.selection.elements[0].element.Pattern).String()
 	for _, item := range e.stage.selection.elements {
@@ -135,17 +175,34 @@ func (e *Editor) updateToolbar() {
 		e.userActions.linearGradient.SetChecked(true)
 		e.userActions.colorA.SetDisabled(false)
 		e.userActions.colorB.SetDisabled(false)
+	case *project.RadialGradient:
+		e.userActions.radialGradient.SetChecked(true)
+		e.userActions.colorA.SetDisabled(false)
+		e.userActions.colorB.SetDisabled(false)
 	}
 
 	return
 patternsOfDifferentType:
 	// the patterns are not the same
 	e.userActions.linearGradient.SetChecked(false)
+	e.userActions.radialGradient.SetChecked(false)
 	e.userActions.solidColor.SetChecked(false)
 	e.userActions.colorA.SetDisabled(true)
 	e.userActions.colorB.SetDisabled(true)
 }
 
+// uncheckGroup unchecks the checked action of an exclusive group so that none of its actions is checked.
+// The ExclusiveOptional policy of Qt 5.14 isn't available, so the group is made non-exclusive while unchecking.
+func uncheckGroup(group *widgets.QActionGroup) {
+	action := group.CheckedAction()
+	if action == nil || action.Pointer() == nil {
+		return
+	}
+	group.SetExclusive(false)
+	action.SetChecked(false)
+	group.SetExclusive(true)
+}
+
 func (e *Editor) KeyPressEvent(event *gui.QKeyEvent) {
 	switch core.Qt__Key(event.Key()) {
 	case core.Qt__Key_Space:
@@ -183,7 +240,16 @@ func (e *Editor) KeyPressEvent(event *gui.QKeyEvent) {
 		e.stage.debugShowZIndex = !e.stage.debugShowZIndex
 		e.stage.redraw()
 	case core.Qt__Key_4:
-		pixel := e.stage.needlePipeline.LastFrame.Pixels
+		output := e.stage.previewOutput()
+		if output == nil {
+			break
+		}
+		var frame scanner.Frame
+		output.LastFrameInto(&frame)
+		pixel := frame.Pixels
+		if len(pixel) == 0 {
+			break
+		}
 		c := pixel[len(pixel)/2]
 		r, g, b, a := c.RGBA()
 		logrus.Debugf("direct: %d %d %d %d | gamma: %.f %.f %.f %.f",
@@ -192,6 +258,9 @@ func (e *Editor) KeyPressEvent(event *gui.QKeyEvent) {
 			math.Pow(float64(g)/0xffff, 2.2)*255,
 			math.Pow(float64(b)/0xffff, 2.2)*255,
 			math.Pow(float64(a)/0xffff, 2.2)*255)
+	case core.Qt__Key_5:
+		stats := e.stage.needlePipeline.Stats()
+		logrus.Debugf("frames rendered: %d | dropped: %d | late: %d", stats.Rendered, stats.Dropped, stats.Late)
 	case core.Qt__Key_0:
 		e.player.SetPlaybackRate(1)
 	case core.Qt__Key_9:
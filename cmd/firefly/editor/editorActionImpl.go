@@ -9,13 +9,16 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 
 	"github.com/omniskop/firefly/pkg/project/vectorpath"
 
 	"github.com/omniskop/firefly/cmd/firefly/settings"
 
 	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/show"
 	"github.com/omniskop/firefly/pkg/storage"
+	"github.com/omniskop/firefly/pkg/streamer"
 	"github.com/sirupsen/logrus"
 	"github.com/therecipe/qt/core"
 	"github.com/therecipe/qt/gui"
@@ -40,8 +43,9 @@ func (e *Editor) ToolbarPatternAction(action *widgets.QAction) {
 	switch p := e.stage.selection.elements[0].element.Pattern.(type) {
 	case *project.SolidColor:
 		col = p.Color
-	case *project.LinearGradient:
-		col = p.Start.Color
+	case project.Gradient:
+		start, _ := p.Anchors()
+		col = start.Color
 	}
 
 	// now we create the new pattern
@@ -50,6 +54,8 @@ func (e *Editor) ToolbarPatternAction(action *widgets.QAction) {
 		newPattern = project.NewSolidColor(col)
 	} else if e.userActions.linearGradient.IsChecked() {
 		newPattern = project.NewLinearGradient(col, col)
+	} else if e.userActions.radialGradient.IsChecked() {
+		newPattern = project.NewRadialGradient(col, col)
 	} else {
 		return
 	}
@@ -76,8 +82,9 @@ func (e *Editor) ToolbarColorAAction(bool) {
 	switch p := e.stage.selection.elements[0].element.Pattern.(type) {
 	case *project.SolidColor:
 		col = p.Color
-	case *project.LinearGradient:
-		col = p.Start.Color
+	case project.Gradient:
+		start, _ := p.Anchors()
+		col = start.Color
 	}
 
 	qcolor := widgets.QColorDialog_GetColor(NewQColorFromColor(col), e.window, "Choose Color", widgets.QColorDialog__ShowAlphaChannel)
@@ -90,8 +97,9 @@ func (e *Editor) ToolbarColorAAction(bool) {
 		switch p := item.element.Pattern.(type) {
 		case *project.SolidColor:
 			p.Color = col
-		case *project.LinearGradient:
-			p.Start.Color = col
+		case project.Gradient:
+			start, _ := p.Anchors()
+			start.Color = col
 		}
 		item.updatePattern()
 	}
@@ -108,8 +116,9 @@ func (e *Editor) ToolbarColorBAction(bool) {
 	switch p := e.stage.selection.elements[0].element.Pattern.(type) {
 	case *project.SolidColor:
 		col = p.Color
-	case *project.LinearGradient:
-		col = p.Stop.Color
+	case project.Gradient:
+		_, stop := p.Anchors()
+		col = stop.Color
 	}
 
 	qcolor := widgets.QColorDialog_GetColor(NewQColorFromColor(col), e.window, "Choose Color", widgets.QColorDialog__ShowAlphaChannel)
@@ -122,13 +131,49 @@ func (e *Editor) ToolbarColorBAction(bool) {
 		switch p := item.element.Pattern.(type) {
 		case *project.SolidColor:
 			p.Color = col
-		case *project.LinearGradient:
-			p.Stop.Color = col
+		case project.Gradient:
+			_, stop := p.Anchors()
+			stop.Color = col
 		}
 		item.updatePattern()
 	}
 }
 
+func (e *Editor) blendModeAction(action *widgets.QAction) {
+	if e.stage.selection.isEmpty() {
+		return
+	}
+
+	for i, modeAction := range e.userActions.blendModes {
+		if modeAction.Pointer() != action.Pointer() {
+			continue
+		}
+		for _, item := range e.stage.selection.elements {
+			item.element.BlendMode = project.BlendModes[i]
+		}
+	}
+	e.stage.updateNeedleFrame()
+}
+
+func (e *Editor) interpolationAction(action *widgets.QAction) {
+	if e.stage.selection.isEmpty() {
+		return
+	}
+
+	for i, interpolationAction := range e.userActions.interpolations {
+		if interpolationAction.Pointer() != action.Pointer() {
+			continue
+		}
+		for _, item := range e.stage.selection.elements {
+			if gradient, ok := item.element.Pattern.(project.Gradient); ok {
+				gradient.SetColorInterpolation(project.ColorInterpolations[i])
+				item.updatePattern()
+			}
+		}
+	}
+	e.stage.updateNeedleFrame()
+}
+
 func (e *Editor) CopyAction(bool) {
 	if e.stage.selection.isEmpty() {
 		return
@@ -345,6 +390,138 @@ func (e *Editor) mirrorElementAction(bool) {
 	}
 }
 
+// checkPowerAction estimates the current that every output of the live LED strip draws during the whole project
+// and warns if it exceeds the limit of its power supply. The project is rendered in the background.
+func (e *Editor) checkPowerAction(bool) {
+	outputs := liveOutputs()
+	if len(outputs) == 0 {
+		widgets.NewQMessageBox2(widgets.QMessageBox__Information, "Power Budget", "No outputs have been configured in the settings.", widgets.QMessageBox__Ok, e.window, core.Qt__Dialog).Exec()
+		return
+	}
+
+	// the whole project is rendered which takes too long for the Qt thread
+	estimates := make([]streamer.PowerEstimate, len(outputs))
+	work := func(task *backgroundTask) {
+		for i, output := range outputs {
+			estimates[i] = streamer.EstimateCurrent(e.project, output.Mapping, output.PowerModel(), notifyInterval/1000.0, func(done float64) bool {
+				return task.report((float64(i) + done) / float64(len(outputs)))
+			})
+		}
+	}
+	runInBackground(e.window, "Estimating the current of the outputs...", work, func(canceled bool) {
+		if !canceled {
+			e.showPowerEstimates(outputs, estimates)
+		}
+	})
+}
+
+// showPowerEstimates tells the user whether the estimates exceed the limits of the outputs
+func (e *Editor) showPowerEstimates(outputs []streamer.OutputConfig, estimates []streamer.PowerEstimate) {
+	icon := widgets.QMessageBox__Information
+	var paragraphs []string
+	for i, output := range outputs {
+		model := output.PowerModel()
+		estimate := estimates[i]
+
+		text := fmt.Sprintf("%s: The highest estimated current is %.2fA at %.2f seconds.", output.Name, estimate.PeakCurrent/1000, estimate.PeakTime)
+		switch {
+		case !model.Enabled():
+			text += "\nNo power limit has been configured in the settings."
+		case estimate.Exceeded > 0:
+			icon = widgets.QMessageBox__Warning
+			text += fmt.Sprintf(
+				"\nThe limit of %.2fA is exceeded for %.2f seconds. The brightness will be reduced during that time.",
+				model.Limit/1000, estimate.Exceeded,
+			)
+		default:
+			text += fmt.Sprintf("\nThe limit of %.2fA is never exceeded.", model.Limit/1000)
+		}
+		paragraphs = append(paragraphs, text)
+	}
+	widgets.NewQMessageBox2(icon, "Power Budget", strings.Join(paragraphs, "\n\n"), widgets.QMessageBox__Ok, e.window, core.Qt__Dialog).Exec()
+}
+
+// recordOutputAction starts or stops recording the frames that are sent to the output shown in the preview
+func (e *Editor) recordOutputAction(checked bool) {
+	if !checked {
+		e.stage.stopRecording()
+		return
+	}
+
+	recordingPath := widgets.QFileDialog_GetSaveFileName(e.window, "Record Live Output", "./recording.ffr", "Firefly Recording (*.ffr)", "", 0)
+	if recordingPath == "" {
+		e.userActions.recordOutput.SetChecked(false)
+		return
+	}
+	err := e.stage.startRecording(recordingPath)
+	if err != nil {
+		logrus.Errorf("recording could not be started: %v", err)
+		text := fmt.Sprintf("The recording could not be started: %v", err)
+		widgets.NewQMessageBox2(widgets.QMessageBox__Critical, "Recording", text, widgets.QMessageBox__Ok, e.window, core.Qt__Dialog).Exec()
+		e.userActions.recordOutput.SetChecked(false)
+	}
+}
+
+// exportShowAction renders the project for the first output into a show file that controllers can play on their own
+func (e *Editor) exportShowAction(bool) {
+	outputs := liveOutputs()
+	if len(outputs) == 0 {
+		widgets.NewQMessageBox2(widgets.QMessageBox__Information, "Export Show", "No outputs have been configured in the settings.", widgets.QMessageBox__Ok, e.window, core.Qt__Dialog).Exec()
+		return
+	}
+	output := outputs[0]
+
+	showPath := widgets.QFileDialog_GetSaveFileName(e.window, "Export Show", "./show.ffs", "Firefly Show (*.ffs)", "", 0)
+	if showPath == "" {
+		return
+	}
+	frameRate := settings.GetInt("liveLedStrip/frameRate")
+	if frameRate <= 0 {
+		frameRate = streamer.DefaultFrameRate
+	}
+	var header show.Header
+	var err error
+	work := func(task *backgroundTask) {
+		header, err = exportShow(showPath, e.project, output, frameRate, task.report)
+	}
+	runInBackground(e.window, "Exporting the show...", work, func(canceled bool) {
+		if canceled {
+			return
+		}
+		if err != nil {
+			logrus.Errorf("show could not be exported: %v", err)
+			text := fmt.Sprintf("The show could not be exported: %v", err)
+			widgets.NewQMessageBox2(widgets.QMessageBox__Critical, "Export Show", text, widgets.QMessageBox__Ok, e.window, core.Qt__Dialog).Exec()
+			return
+		}
+		text := fmt.Sprintf("The show has been exported for %s with %d frames of %d pixels at %d frames per second.",
+			output.Name, header.Frames, header.Pixels, header.FrameRate)
+		widgets.NewQMessageBox2(widgets.QMessageBox__Information, "Export Show", text, widgets.QMessageBox__Ok, e.window, core.Qt__Dialog).Exec()
+	})
+}
+
+// exportShow writes the show into a temporary file that replaces the file at the path once the show is complete.
+// That way a failed or canceled export doesn't leave an incomplete show behind.
+func exportShow(path string, proj *project.Project, output streamer.OutputConfig, frameRate int, progress func(float64) bool) (show.Header, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return show.Header{}, err
+	}
+	header, err := show.Export(file, proj, output, frameRate, progress)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return header, err
+	}
+	return header, nil
+}
+
 func copyFile(dst, src string) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
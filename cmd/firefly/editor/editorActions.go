@@ -2,7 +2,9 @@ package editor
 
 import (
 	"runtime"
+	"strings"
 
+	"github.com/omniskop/firefly/pkg/project"
 	"github.com/omniskop/firefly/pkg/project/shape"
 	"github.com/sirupsen/logrus"
 	"github.com/therecipe/qt/core"
@@ -31,10 +33,21 @@ type editorActions struct {
 
 	solidColor     *widgets.QAction
 	linearGradient *widgets.QAction
+	radialGradient *widgets.QAction
 	patternGroup   *widgets.QActionGroup
 	colorA         *widgets.QAction
 	colorB         *widgets.QAction
 
+	blendModes     []*widgets.QAction // one action for each mode in project.BlendModes
+	blendModeGroup *widgets.QActionGroup
+
+	interpolations     []*widgets.QAction // one action for each interpolation in project.ColorInterpolations
+	interpolationGroup *widgets.QActionGroup
+
+	checkPower   *widgets.QAction
+	recordOutput *widgets.QAction
+	exportShow   *widgets.QAction
+
 	openLogConsole *widgets.QAction
 }
 
@@ -81,13 +94,47 @@ func newEditorActions() *editorActions {
 	actions.solidColor = newCheckableQActionWithIcon("Solid Color", ":assets/images/toolbar solid color.imageset/toolbar solid color.png")
 	actions.solidColor.SetChecked(true)
 	actions.linearGradient = newCheckableQActionWithIcon("Linear Gradient", ":assets/images/toolbar linear gradient.imageset/toolbar linear gradient.png")
+	actions.radialGradient = newCheckableQActionWithIcon("Radial Gradient", ":assets/images/toolbar radial gradient.imageset/toolbar radial gradient.png")
 	actions.patternGroup = widgets.NewQActionGroup(nil)
 	actions.patternGroup.AddAction(actions.solidColor)
 	actions.patternGroup.AddAction(actions.linearGradient)
+	actions.patternGroup.AddAction(actions.radialGradient)
 	actions.colorA = newQActionWithIcon("Choose Color", ":assets/images/toolbar colorpicker.imageset/toolbar colorpicker.png")
 	actions.colorB = newQActionWithIcon("Choose Second Color", ":assets/images/toolbar colorpicker.imageset/toolbar colorpicker.png")
 	actions.colorB.SetDisabled(true)
 
+	actions.blendModeGroup = widgets.NewQActionGroup(nil)
+	for _, mode := range project.BlendModes {
+		name := mode.String()
+		action := widgets.NewQAction2(strings.ToUpper(name[:1])+name[1:], nil)
+		action.SetCheckable(true)
+		actions.blendModeGroup.AddAction(action)
+		actions.blendModes = append(actions.blendModes, action)
+	}
+	actions.blendModes[project.BlendNormal].SetChecked(true)
+
+	interpolationNames := map[project.ColorInterpolation]string{
+		project.InterpolateSRGB:      "sRGB",
+		project.InterpolateLinearRGB: "Linear RGB",
+		project.InterpolateHSVShort:  "HSV",
+		project.InterpolateHSVLong:   "HSV (Long Hue)",
+		project.InterpolateOKLab:     "OKLab",
+	}
+	actions.interpolationGroup = widgets.NewQActionGroup(nil)
+	for _, interpolation := range project.ColorInterpolations {
+		action := widgets.NewQAction2(interpolationNames[interpolation], nil)
+		action.SetCheckable(true)
+		action.SetDisabled(true)
+		actions.interpolationGroup.AddAction(action)
+		actions.interpolations = append(actions.interpolations, action)
+	}
+	actions.interpolations[project.InterpolateSRGB].SetChecked(true)
+
+	actions.checkPower = widgets.NewQAction2("Check Power Budget...", nil)
+	actions.recordOutput = widgets.NewQAction2("Record Live Output...", nil)
+	actions.recordOutput.SetCheckable(true)
+	actions.exportShow = widgets.NewQAction2("Export Show File...", nil)
+
 	actions.openLogConsole = widgets.NewQAction2("Console", nil)
 
 	return actions
@@ -111,6 +158,11 @@ func (actions *editorActions) connectToEditor(e *Editor) {
 	e.userActions.patternGroup.ConnectTriggered(e.ToolbarPatternAction)
 	e.userActions.colorA.ConnectTriggered(e.ToolbarColorAAction)
 	e.userActions.colorB.ConnectTriggered(e.ToolbarColorBAction)
+	e.userActions.blendModeGroup.ConnectTriggered(e.blendModeAction)
+	e.userActions.interpolationGroup.ConnectTriggered(e.interpolationAction)
+	e.userActions.checkPower.ConnectTriggered(e.checkPowerAction)
+	e.userActions.recordOutput.ConnectTriggered(e.recordOutputAction)
+	e.userActions.exportShow.ConnectTriggered(e.exportShowAction)
 	e.userActions.openLogConsole.ConnectTriggered(func(checked bool) {
 		e.applicationCallbacks["openLogConsole"]()
 	})
@@ -151,6 +203,7 @@ func (actions *editorActions) buildToolbar() *widgets.QToolBar {
 	bar.AddActions([]*widgets.QAction{
 		actions.solidColor,
 		actions.linearGradient,
+		actions.radialGradient,
 		actions.colorA,
 		actions.colorB,
 	})
@@ -191,6 +244,16 @@ func (actions *editorActions) buildMenuBar() *widgets.QMenuBar {
 	editMenu.AddActions([]*widgets.QAction{
 		actions.mirrorElement,
 	})
+	blendModeMenu := editMenu.AddMenu2("Blend Mode")
+	blendModeMenu.AddActions(actions.blendModes)
+	interpolationMenu := editMenu.AddMenu2("Gradient Interpolation")
+	interpolationMenu.AddActions(actions.interpolations)
+	outputMenu := menubar.AddMenu2("Output")
+	outputMenu.AddActions([]*widgets.QAction{
+		actions.checkPower,
+		actions.recordOutput,
+		actions.exportShow,
+	})
 	helpMenu := menubar.AddMenu2("Help")
 	helpMenu.AddActions([]*widgets.QAction{
 		actions.openLogConsole,
@@ -44,11 +44,13 @@ func newElementGraphicsItem(parentStage *stage, element *project.Element) *eleme
 	item.SetPen(noPen)
 	item.SetFlags(widgets.QGraphicsItem__ItemSendsScenePositionChanges | widgets.QGraphicsItem__ItemIsMovable)
 	item.ConnectMousePressEvent(item.mousePressEvent)
+	item.ConnectMouseDoubleClickEvent(item.mouseDoubleClickEvent)
 	item.ConnectItemChange(item.itemChangeEvent)
 	return &item
 }
 
 func (item *elementGraphicsItem) updatePath() {
+	item.parent.projectScene.UpdateElement(item.element) // the shape of the element has probably changed
 	item.ignoreNextPositionChange = 1
 	item.PrepareGeometryChange()
 	item.SetPos(qtPoint(item.element.Shape.Origin()))
@@ -70,7 +72,7 @@ func (item *elementGraphicsItem) updatePattern() {
 			item.Scene().RemoveItem(item.gradientItem)
 			item.gradientItem = nil
 		}
-	case *project.LinearGradient:
+	case project.Gradient:
 		if item.gradientItem == nil {
 			item.gradientItem = newGradientGraphicsItem(item, pat)
 		} else {
@@ -102,6 +104,16 @@ func (item *elementGraphicsItem) mousePressEvent(event *widgets.QGraphicsSceneMo
 	item.MousePressEventDefault(event)
 }
 
+func (item *elementGraphicsItem) mouseDoubleClickEvent(event *widgets.QGraphicsSceneMouseEvent) {
+	if item.gradientItem != nil {
+		// double clicking on an element with a visible gradient adds a new color step at that position
+		event.Accept()
+		item.gradientItem.addStep(vpPoint(event.Pos()))
+		return
+	}
+	item.MouseDoubleClickEventDefault(event)
+}
+
 func (item *elementGraphicsItem) itemChangeEvent(change widgets.QGraphicsItem__GraphicsItemChange, value *core.QVariant) *core.QVariant {
 	if change == widgets.QGraphicsItem__ItemPositionChange {
 		if item.parent.creationElement != nil && item.parent.creationElement.Pointer() != item.Pointer() {
@@ -120,6 +132,7 @@ func (item *elementGraphicsItem) itemChangeEvent(change widgets.QGraphicsItem__G
 			// This item has been moved because it is part of a selection.
 			// We will leave here because we don't need to update other elements.
 			item.element.Shape.SetOrigin(vpPoint(newPos))
+			item.parent.projectScene.UpdateElement(item.element)
 			item.ignoreNextPositionChange = 0
 			goto end
 		}
@@ -140,6 +153,7 @@ func (item *elementGraphicsItem) itemChangeEvent(change widgets.QGraphicsItem__G
 		}
 
 		item.element.Shape.SetOrigin(vpPoint(newPos))
+		item.parent.projectScene.UpdateElement(item.element)
 
 		// update other selected elements
 		oldPos := item.Pos()
@@ -173,8 +187,8 @@ func (item *elementGraphicsItem) showHandles() {
 
 	// gradient
 	if item.element.Pattern != nil {
-		if linearGradient, ok := item.element.Pattern.(*project.LinearGradient); ok {
-			item.gradientItem = newGradientGraphicsItem(item, linearGradient)
+		if gradient, ok := item.element.Pattern.(project.Gradient); ok {
+			item.gradientItem = newGradientGraphicsItem(item, gradient)
 		}
 	}
 }
@@ -197,6 +211,11 @@ func (item *elementGraphicsItem) hideHandles() {
 func pathFromElement(element *project.Element) *gui.QPainterPath {
 	elementPath := element.Shape.Path()
 	path := gui.NewQPainterPath()
+	if elementPath.FillRule == vectorpath.NonZeroFill {
+		path.SetFillRule(core.Qt__WindingFill)
+	} else {
+		path.SetFillRule(core.Qt__OddEvenFill)
+	}
 	currentPosition := vectorpath.Point{P: 0, T: 0}
 	path.MoveTo(qtPoint(currentPosition))
 
@@ -2,6 +2,7 @@ package editor
 
 import (
 	"image/color"
+	"math"
 
 	"github.com/sirupsen/logrus"
 
@@ -24,18 +25,28 @@ func init() {
 	gradientLinePen.SetCosmetic(true) // prevent the pen from being transformed
 }
 
+// gradientGraphicsItem shows the line of a gradient together with dots for the anchor points and color steps.
+// The dots can be moved to change the gradient. Double clicking a dot opens a color dialog for it and
+// color steps can be removed with a right click.
+// Radial gradients get an additional dot that controls the aspect ratio of the gradient.
 type gradientGraphicsItem struct {
 	*widgets.QGraphicsLineItem
 	parent   *elementGraphicsItem
-	gradient *project.LinearGradient
+	gradient project.Gradient
 	start    *colorDotItem
 	stop     *colorDotItem
+	steps    []*colorDotItem
+	aspect   *colorDotItem // only present for radial gradients
 }
 
-func newGradientGraphicsItem(parent *elementGraphicsItem, gradient *project.LinearGradient) *gradientGraphicsItem {
+// the index of the dot that controls the aspect ratio of a radial gradient
+const aspectDotIndex = -3
+
+func newGradientGraphicsItem(parent *elementGraphicsItem, gradient project.Gradient) *gradientGraphicsItem {
+	start, stop := gradient.Anchors()
 	line := core.NewQLineF2(
-		qtPoint(parent.element.MapLocalToRelative(gradient.Start.Point)),
-		qtPoint(parent.element.MapLocalToRelative(gradient.Stop.Point)),
+		qtPoint(parent.element.MapLocalToRelative(start.Point)),
+		qtPoint(parent.element.MapLocalToRelative(stop.Point)),
 	)
 	item := gradientGraphicsItem{
 		QGraphicsLineItem: widgets.NewQGraphicsLineItem2(line, parent),
@@ -43,8 +54,10 @@ func newGradientGraphicsItem(parent *elementGraphicsItem, gradient *project.Line
 		gradient:          gradient,
 	}
 
-	item.start = newColorDotGraphicsItem(&item, parent.element.MapLocalToRelative(gradient.Start.Point), gradient.Start.Color, -1)
-	item.stop = newColorDotGraphicsItem(&item, parent.element.MapLocalToRelative(gradient.Stop.Point), gradient.Stop.Color, -2)
+	item.start = newColorDotGraphicsItem(&item, parent.element.MapLocalToRelative(start.Point), start.Color, -1)
+	item.stop = newColorDotGraphicsItem(&item, parent.element.MapLocalToRelative(stop.Point), stop.Color, -2)
+	item.updateSteps()
+	item.updateAspect()
 
 	item.SetPen(gradientLinePen)
 
@@ -59,19 +72,107 @@ func newGradientGraphicsItem(parent *elementGraphicsItem, gradient *project.Line
 }
 
 func (item *gradientGraphicsItem) updateShape(except int) {
+	start, stop := item.gradient.Anchors()
 	// TODO: change line instead of replacing it
 	item.QGraphicsLineItem.SetLine(core.NewQLineF2(
-		qtPoint(item.parent.element.MapLocalToRelative(item.gradient.Start.Point)),
-		qtPoint(item.parent.element.MapLocalToRelative(item.gradient.Stop.Point)),
+		qtPoint(item.parent.element.MapLocalToRelative(start.Point)),
+		qtPoint(item.parent.element.MapLocalToRelative(stop.Point)),
 	))
-	item.start.update(item.parent.element.MapLocalToRelative(item.gradient.Start.Point), item.gradient.Start.Color, -1)
-	item.stop.update(item.parent.element.MapLocalToRelative(item.gradient.Stop.Point), item.gradient.Stop.Color, -2)
+	item.start.update(item.parent.element.MapLocalToRelative(start.Point), start.Color, -1)
+	item.stop.update(item.parent.element.MapLocalToRelative(stop.Point), stop.Color, -2)
+	item.updateSteps()
+	item.updateAspect()
 }
 
-func (item *gradientGraphicsItem) updateGradient(gradient *project.LinearGradient) {
+func (item *gradientGraphicsItem) updateGradient(gradient project.Gradient) {
 	item.gradient = gradient
-	item.start.update(item.parent.element.MapLocalToRelative(item.gradient.Start.Point), gradient.Start.Color, -1)
-	item.stop.update(item.parent.element.MapLocalToRelative(item.gradient.Stop.Point), gradient.Stop.Color, -2)
+	start, stop := gradient.Anchors()
+	item.start.update(item.parent.element.MapLocalToRelative(start.Point), start.Color, -1)
+	item.stop.update(item.parent.element.MapLocalToRelative(stop.Point), stop.Color, -2)
+	item.updateSteps()
+	item.updateAspect()
+}
+
+// updateSteps makes sure that there is a dot for every color step of the gradient and that they are up to date
+func (item *gradientGraphicsItem) updateSteps() {
+	steps := item.gradient.ColorSteps()
+	start, stop := item.gradient.Anchors()
+
+	// remove dots of steps that don't exist anymore
+	for len(item.steps) > len(steps) {
+		last := item.steps[len(item.steps)-1]
+		last.Scene().RemoveItem(last)
+		item.steps = item.steps[:len(item.steps)-1]
+	}
+
+	for i, step := range steps {
+		position := item.parent.element.MapLocalToRelative(vectorpath.Interpolate(start.Point, stop.Point, step.Position))
+		if i < len(item.steps) {
+			item.steps[i].update(position, step.Color, i)
+		} else {
+			item.steps = append(item.steps, newColorDotGraphicsItem(item, position, step.Color, i))
+		}
+	}
+}
+
+// updateAspect makes sure that radial gradients have a dot for the aspect ratio and that it is up to date.
+// The dot is located on the time axis below the center of the gradient where the gradient reaches the edge color.
+func (item *gradientGraphicsItem) updateAspect() {
+	radial, ok := item.gradient.(*project.RadialGradient)
+	if !ok {
+		if item.aspect != nil {
+			item.aspect.Scene().RemoveItem(item.aspect)
+			item.aspect = nil
+		}
+		return
+	}
+
+	position := item.parent.element.MapLocalToRelative(radial.Center.Point.Add(vectorpath.Point{
+		P: 0,
+		T: radial.Radius() * radial.Aspect(),
+	}))
+	if item.aspect == nil {
+		item.aspect = newColorDotGraphicsItem(item, position, radial.Edge.Color, aspectDotIndex)
+	} else {
+		item.aspect.update(position, radial.Edge.Color, aspectDotIndex)
+	}
+}
+
+// addStep adds a new color step to the gradient at the position of the point in relative coordinates
+func (item *gradientGraphicsItem) addStep(point vectorpath.Point) {
+	item.gradient.AddStep(item.gradient.Progress(item.parent.element.MapRelativeToLocal(point)))
+	item.updateSteps()
+	item.parent.updatePattern()
+}
+
+// removeStep removes the color step with the index from the gradient
+func (item *gradientGraphicsItem) removeStep(index int) {
+	item.gradient.RemoveStep(index)
+	item.updateSteps()
+	item.parent.updatePattern()
+}
+
+// chooseColor lets the user pick a new color for the anchor point or color step with the index
+func (item *gradientGraphicsItem) chooseColor(index int) {
+	start, stop := item.gradient.Anchors()
+	steps := item.gradient.ColorSteps()
+	var col *color.Color
+	if index == -1 { // Start
+		col = &start.Color
+	} else if index == -2 { // Stop
+		col = &stop.Color
+	} else if index >= 0 && index < len(steps) { // color step
+		col = &steps[index].Color
+	} else {
+		return
+	}
+
+	qcolor := widgets.QColorDialog_GetColor(NewQColorFromColor(*col), item.parent.parent.editor.window, "Choose Color", widgets.QColorDialog__ShowAlphaChannel)
+	if !qcolor.IsValid() { // user canceled dialog
+		return
+	}
+	*col = NewColorFromQColor(qcolor)
+	item.parent.updatePattern()
 }
 
 func (item *gradientGraphicsItem) mousePressEvent(event *widgets.QGraphicsSceneMouseEvent) {
@@ -90,12 +191,18 @@ func (item *gradientGraphicsItem) itemChangeEvent(change widgets.QGraphicsItem__
 
 func (item *gradientGraphicsItem) SetStopPosition(index int, point vectorpath.Point) {
 	point = item.parent.element.MapRelativeToLocal(point)
+	start, stop := item.gradient.Anchors()
+	steps := item.gradient.ColorSteps()
 	if index == -1 { // Start
-		item.gradient.Start.Point = point
+		start.Point = point
 	} else if index == -2 { // Stop
-		item.gradient.Stop.Point = point
-	} else if index < len(item.gradient.Steps) { // color step
-		item.gradient.Steps[index].Position = (point.P - item.gradient.Start.P) / (item.gradient.Stop.P - item.gradient.Start.P)
+		stop.Point = point
+	} else if radial, ok := item.gradient.(*project.RadialGradient); ok && index == aspectDotIndex {
+		if radius := radial.Radius(); radius > 0 {
+			radial.AspectRatio = math.Abs(point.T-radial.Center.Point.T) / radius
+		}
+	} else if index >= 0 && index < len(steps) { // color step
+		steps[index].Position = vectorpath.Clamp(item.gradient.Progress(point), 0, 1)
 	} else {
 		logrus.
 			WithFields(logrus.Fields{"index": index, "point": point}).
@@ -141,6 +248,8 @@ func newColorDotGraphicsItem(parent *gradientGraphicsItem, pos vectorpath.Point,
 
 	// signals
 	item.ConnectItemChange(item.itemChangeEvent)
+	item.ConnectMousePressEvent(item.mousePressEvent)
+	item.ConnectMouseDoubleClickEvent(item.mouseDoubleClickEvent)
 
 	return &item
 }
@@ -157,6 +266,21 @@ func (item *colorDotItem) update(pos vectorpath.Point, col color.Color, index in
 	item.index = index
 }
 
+func (item *colorDotItem) mousePressEvent(event *widgets.QGraphicsSceneMouseEvent) {
+	if event.Button() == core.Qt__RightButton && item.index >= 0 {
+		// color steps can be removed with a right click but the anchor points can't
+		event.Accept()
+		item.parent.removeStep(item.index)
+		return
+	}
+	item.MousePressEventDefault(event)
+}
+
+func (item *colorDotItem) mouseDoubleClickEvent(event *widgets.QGraphicsSceneMouseEvent) {
+	event.Accept()
+	item.parent.chooseColor(item.index)
+}
+
 func (item *colorDotItem) itemChangeEvent(change widgets.QGraphicsItem__GraphicsItemChange, value *core.QVariant) *core.QVariant {
 	if change == widgets.QGraphicsItem__ItemPositionHasChanged {
 		if item.ignoreNextPositionChange {
@@ -0,0 +1,72 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omniskop/firefly/cmd/firefly/settings"
+	"github.com/omniskop/firefly/pkg/streamer"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// outputStatusInterval is the time in milliseconds between two updates of the output status
+const outputStatusInterval = 1000
+
+// outputStatus shows the health of the live outputs in the status bar of the editor
+type outputStatus struct {
+	*widgets.QLabel
+	pipeline *streamer.Pipeline
+	timer    *core.QTimer
+}
+
+func newOutputStatus(pipeline *streamer.Pipeline) *outputStatus {
+	status := &outputStatus{
+		QLabel:   widgets.NewQLabel(nil, 0),
+		pipeline: pipeline,
+	}
+	status.timer = core.NewQTimer(status)
+	status.timer.ConnectTimeout(status.update)
+	status.timer.Start(outputStatusInterval)
+	status.update()
+	return status
+}
+
+func (s *outputStatus) update() {
+	if !settings.GetBool("liveLedStrip/enabled") {
+		s.SetText("Live preview disabled")
+		s.SetToolTip("")
+		return
+	}
+
+	var texts, details []string
+	for _, output := range s.pipeline.Outputs() {
+		health := output.Health()
+		var text string
+		switch {
+		case !health.Enabled:
+			text = "disabled"
+		case health.Failing:
+			text = "failing"
+		case health.Connected:
+			text = "sending"
+		default:
+			text = "waiting"
+		}
+		texts = append(texts, fmt.Sprintf("%s: %s", output.Config.Name, text))
+
+		detail := fmt.Sprintf("%s: %d packets sent, %d failed", output.Config.Name, health.PacketsSent, health.PacketsFailed)
+		if health.LastError != nil {
+			detail += fmt.Sprintf("\nlast error at %s: %v", health.LastErrorTime.Format("15:04:05"), health.LastError)
+		}
+		details = append(details, detail)
+	}
+	if len(texts) == 0 {
+		s.SetText("No outputs configured")
+		s.SetToolTip("")
+		return
+	}
+	s.SetText(strings.Join(texts, " | "))
+	s.SetToolTip(strings.Join(details, "\n\n"))
+}
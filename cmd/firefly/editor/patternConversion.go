@@ -3,10 +3,12 @@ package editor
 import (
 	"fmt"
 	"image/color"
+	"sort"
 
 	"github.com/therecipe/qt/core"
 
 	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/project/vectorpath"
 	"github.com/therecipe/qt/gui"
 )
 
@@ -37,20 +39,78 @@ func NewQLinearGradientFromLinearGradient(grad *project.LinearGradient) *gui.QLi
 		qgradient = gui.NewQLinearGradient3(grad.Start.Point.T, grad.Start.Point.P, grad.Stop.Point.T, grad.Stop.Point.P)
 	}
 	qgradient.SetCoordinateMode(gui.QGradient__ObjectMode) // object mode => (0,0) <-> (1,1)
-	qgradient.SetColorAt(0, NewQColorFromColor(grad.Start))
-	for _, step := range grad.Steps {
-		qgradient.SetColorAt(step.Position, NewQColorFromColor(step.Color))
+	setGradientStops(&qgradient.QGradient, grad)
+	return qgradient
+}
+
+func NewQRadialGradientFromRadialGradient(grad *project.RadialGradient) *gui.QRadialGradient {
+	// like for the linear gradient these are not scene coordinates so we can't use qtPoint
+	var qgradient *gui.QRadialGradient
+	if verticalTimeAxis {
+		qgradient = gui.NewQRadialGradient5(grad.Center.Point.P, grad.Center.Point.T, grad.Radius())
+	} else {
+		qgradient = gui.NewQRadialGradient5(grad.Center.Point.T, grad.Center.Point.P, grad.Radius())
 	}
-	qgradient.SetColorAt(1, NewQColorFromColor(grad.Stop))
+	qgradient.SetCoordinateMode(gui.QGradient__ObjectMode) // object mode => (0,0) <-> (1,1)
+	setGradientStops(&qgradient.QGradient, grad)
 	return qgradient
 }
 
+// newQBrushFromRadialGradient creates a brush for the radial gradient.
+// Qt only supports circular radial gradients, so the aspect ratio is applied as a transformation of the brush.
+// Because the gradient uses the object mode the transformation happens in the local coordinates of the element.
+func newQBrushFromRadialGradient(grad *project.RadialGradient) *gui.QBrush {
+	brush := gui.NewQBrush10(NewQRadialGradientFromRadialGradient(grad))
+	center := grad.Center.Point
+	transform := gui.NewQTransform2()
+	if verticalTimeAxis {
+		transform.Translate(center.P, center.T).Scale(1, grad.Aspect()).Translate(-center.P, -center.T)
+	} else {
+		transform.Translate(center.T, center.P).Scale(grad.Aspect(), 1).Translate(-center.T, -center.P)
+	}
+	brush.SetTransform(transform)
+	return brush
+}
+
+// setGradientStops sets the colors of the qgradient to match the gradient.
+// Qt always interpolates in sRGB. For other color interpolations additional stops are sampled from the gradient
+// so that the preview in the editor matches the colors that the scanner produces.
+func setGradientStops(qgradient *gui.QGradient, grad project.Gradient) {
+	const samplesBetweenStops = 8
+
+	start, stop := grad.Anchors()
+	positions := []float64{0, 1}
+	for _, step := range grad.ColorSteps() {
+		positions = append(positions, vectorpath.Clamp(step.Position, 0, 1))
+	}
+	sort.Float64s(positions)
+
+	qgradient.SetColorAt(0, NewQColorFromColor(start))
+	for _, step := range grad.ColorSteps() {
+		qgradient.SetColorAt(step.Position, NewQColorFromColor(step.Color))
+	}
+	qgradient.SetColorAt(1, NewQColorFromColor(stop))
+
+	if grad.ColorInterpolation() == project.InterpolateSRGB {
+		return
+	}
+	for i := 0; i < len(positions)-1; i++ {
+		from, to := positions[i], positions[i+1]
+		for sample := 1; sample < samplesBetweenStops; sample++ {
+			position := from + (to-from)*float64(sample)/samplesBetweenStops
+			qgradient.SetColorAt(position, NewQColorFromColor(grad.ColorAt(position)))
+		}
+	}
+}
+
 func NewQBrushFromPattern(pat project.Pattern) *gui.QBrush {
 	switch cast := pat.(type) {
 	case *project.SolidColor:
 		return gui.NewQBrush3(NewQColorFromColor(cast), core.Qt__SolidPattern)
 	case *project.LinearGradient:
 		return gui.NewQBrush10(NewQLinearGradientFromLinearGradient(cast))
+	case *project.RadialGradient:
+		return newQBrushFromRadialGradient(cast)
 	default:
 		return gui.NewQBrush3(gui.NewQColor3(240, 107, 255, 255), core.Qt__SolidPattern)
 	}
@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"image/color"
-	"io"
 	"math"
-	"net"
+	"os"
 	"runtime"
-	"strconv"
 	"unsafe"
 
 	"github.com/omniskop/firefly/cmd/firefly/settings"
@@ -43,6 +41,9 @@ type stage struct {
 
 	needlePosition int
 	needlePipeline *streamer.Pipeline
+	previewFrame   scanner.Frame      // the last frame of the preview output, reused while drawing
+	recorder       *streamer.Recorder // records the frames of the preview output
+	recordingFile  *os.File
 
 	nextNonUserScrollEvents uint
 
@@ -62,15 +63,14 @@ func newStage(editor *Editor, projectScene *project.Scene, duration float64) *st
 		projectScene:   projectScene,
 		editor:         editor,
 		duration:       duration,
-		needlePipeline: streamer.NewPipeline(scanner.New(projectScene, 30), streamer.NewWLED(nil)),
+		needlePipeline: streamer.NewPipeline(),
 		selection:      elementList{onChange: editor.selectionChanged},
 		items:          make(map[unsafe.Pointer]*elementGraphicsItem),
 	}
 
 	settings.OnChange("liveLedStrip/enabled", s.updatePipeline)
-	settings.OnChange("liveLedStrip/address", s.updatePipeline)
-	settings.OnChange("liveLedStrip/port", s.updatePipeline)
-	settings.OnChange("liveLedStrip/mapping", s.updatePipeline)
+	settings.OnChange("liveLedStrip/frameRate", s.updatePipeline)
+	settings.OnChange("liveLedStrip/outputs", s.updatePipeline)
 	s.updatePipeline(nil)
 
 	s.SetObjectName("mainEditorView")
@@ -113,31 +113,96 @@ func newStage(editor *Editor, projectScene *project.Scene, duration float64) *st
 }
 
 func (s *stage) updatePipeline(interface{}) {
-	var streamerWriter io.Writer
-	if settings.GetBool("liveLedStrip/enabled") {
-		address := settings.GetString("liveLedStrip/address")
-		port := settings.GetInt("liveLedStrip/port")
-		if address != "" && port != 0 {
-			var err error
-			streamerWriter, err = streamer.NewUDPWriter(net.JoinHostPort(settings.GetString("liveLedStrip/address"), strconv.Itoa(settings.GetInt("liveLedStrip/port"))))
-			if err != nil {
-				logrus.Error(err)
-				streamerWriter = nil
-			}
+	enabled := settings.GetBool("liveLedStrip/enabled")
+	frameRate := settings.GetInt("liveLedStrip/frameRate")
+	if frameRate <= 0 {
+		frameRate = streamer.DefaultFrameRate
+	}
+	s.needlePipeline.SetFrameRate(float64(frameRate))
+	var outputs []*streamer.Output
+	for _, config := range liveOutputs() {
+		config.Enabled = config.Enabled && enabled
+		output, err := streamer.NewOutput(s.projectScene, config)
+		if err != nil {
+			logrus.Errorf("output %q: %v", config.Name, err)
+			continue
+		}
+		// the samples are spread across the time between two frames
+		output.Scanner.SetMotionBlur(config.MotionBlurSamples, 1/float64(frameRate))
+		err = output.Connect()
+		if err != nil {
+			logrus.Errorf("output %q: %v", config.Name, err)
+		}
+		outputs = append(outputs, output)
+	}
+	if s.recorder != nil {
+		// the previous outputs are closed in the background and must not record anymore
+		for _, output := range s.needlePipeline.Outputs() {
+			output.Record(nil)
+		}
+		if len(outputs) > 0 {
+			outputs[0].Record(s.recorder)
 		}
 	}
+	s.needlePipeline.SetOutputs(outputs)
+}
+
+// startRecording records the frames of the preview output into the file at the path
+func (s *stage) startRecording(path string) error {
+	s.stopRecording()
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	s.recordingFile = file
+	s.recorder = streamer.NewRecorder(file)
+	if output := s.previewOutput(); output != nil {
+		output.Record(s.recorder)
+	}
+	return nil
+}
 
-	rawMapping := settings.GetString("liveLedStrip/mapping")
-	var mapping scanner.Mapping
-	err := json.Unmarshal([]byte(rawMapping), &mapping)
+// stopRecording finishes the current recording
+func (s *stage) stopRecording() {
+	if s.recorder == nil {
+		return
+	}
+	// Record waits for frames that are being recorded, so the recorder can be flushed afterwards
+	for _, output := range s.needlePipeline.Outputs() {
+		output.Record(nil)
+	}
+	err := s.recorder.Flush()
+	if err != nil {
+		logrus.Errorf("recording failed: %v", err)
+	}
+	err = s.recordingFile.Close()
 	if err != nil {
-		mapping = *scanner.NewLinearMapping(30)
+		logrus.Errorf("recording could not be saved: %v", err)
+	}
+	s.recorder = nil
+	s.recordingFile = nil
+}
+
+// liveOutputs returns the configuration of all outputs of the live LED strip
+func liveOutputs() []streamer.OutputConfig {
+	var outputs []streamer.OutputConfig
+	if rawOutputs := settings.GetString("liveLedStrip/outputs"); rawOutputs != "" {
+		err := json.Unmarshal([]byte(rawOutputs), &outputs)
+		if err != nil {
+			logrus.Errorf("invalid outputs: %v", err)
+			return nil
+		}
 	}
+	return outputs
+}
 
-	s.needlePipeline.Scanner.SetMapping(mapping)
-	str := s.needlePipeline.Streamers[0].(*streamer.WLEDStreamer)
-	str.SetDestination(streamerWriter)
-	s.needlePipeline.Streamers[0] = str
+// previewOutput returns the output that is shown in the preview at the needle or nil if there is none
+func (s *stage) previewOutput() *streamer.Output {
+	outputs := s.needlePipeline.Outputs()
+	if len(outputs) == 0 {
+		return nil
+	}
+	return outputs[0]
 }
 
 func (s *stage) createElements() {
@@ -172,16 +237,16 @@ func (s *stage) createElements() {
 	songTitle.SetBrush(gui.NewQBrush3(gui.NewQColor3(201, 201, 201, 255), core.Qt__SolidPattern))
 	songTitle.SetPos2(5, -35)
 
-	for i := range s.projectScene.Elements {
-		item := newElementGraphicsItem(s, s.projectScene.Elements[i])
+	for _, element := range s.projectScene.Elements() {
+		item := newElementGraphicsItem(s, element)
 		s.items[item.Pointer()] = item
 		s.scene.AddItem(item)
 	}
 }
 
 func (s *stage) addElement(element *project.Element) *elementGraphicsItem {
-	s.projectScene.Elements = append(s.projectScene.Elements, element)
-	item := newElementGraphicsItem(s, s.projectScene.Elements[len(s.projectScene.Elements)-1])
+	s.projectScene.AddElement(element)
+	item := newElementGraphicsItem(s, element)
 	s.items[item.Pointer()] = item
 	s.scene.AddItem(item)
 	return item
@@ -200,17 +265,9 @@ func (s *stage) removeElement(item *elementGraphicsItem) {
 
 	delete(s.items, item.Pointer())
 	s.scene.RemoveItem(item)
-	for i := range s.projectScene.Elements {
-		if s.projectScene.Elements[i] == item.element {
-			// this copies the last element of the slice over the removed one and shrinks the slice
-			lastIndex := len(s.projectScene.Elements) - 1
-			s.projectScene.Elements[i] = s.projectScene.Elements[lastIndex]
-			s.projectScene.Elements[lastIndex] = nil
-			s.projectScene.Elements = s.projectScene.Elements[:lastIndex]
-			return
-		}
+	if !s.projectScene.RemoveElement(item.element) {
+		logrus.Error("an element that should have been deleted could not be found in the scene")
 	}
-	logrus.Error("an element that should have been deleted could not be found in the scene")
 }
 
 func (s *stage) removeElements(items []*elementGraphicsItem) {
@@ -335,7 +392,12 @@ func (s *stage) setTime(t float64) {
 }
 
 func (s *stage) updateNeedleFrame() {
-	s.needlePipeline.Update <- s.editor.player.time()
+	// while playing the pipeline extrapolates the time between two updates
+	var rate float64
+	if s.editor.playing {
+		rate = s.editor.player.PlaybackRate()
+	}
+	s.needlePipeline.Sync(s.editor.player.time(), rate)
 }
 
 func (s *stage) scrollSceneToLogical(scenePoint *core.QPointF, viewportPoint *core.QPoint) {
@@ -448,14 +510,17 @@ func (s *stage) sceneMousePressEvent(event *widgets.QGraphicsSceneMouseEvent) {
 
 	if s.editor.userActions.toolGroup.CheckedAction().Pointer() != s.editor.userActions.cursor.Pointer() {
 		var elementColor project.Pattern = project.NewSolidColorRGBA(255, 255, 255, 255)
+		var blendMode = project.BlendNormal
 		if !s.selection.isEmpty() {
 			// we copy the style of the first selected element
 			elementColor = s.selection.elements[0].element.Pattern.Copy()
+			blendMode = s.selection.elements[0].element.BlendMode
 		}
 		s.creationElement = newElementGraphicsItem(s, &project.Element{
-			ZIndex:  s.newZIndex,
-			Shape:   s.editor.userActions.getSelectedShape(),
-			Pattern: elementColor,
+			ZIndex:    s.newZIndex,
+			Shape:     s.editor.userActions.getSelectedShape(),
+			Pattern:   elementColor,
+			BlendMode: blendMode,
 		})
 		s.newZIndex += zIndexSteps
 		s.items[s.creationElement.Pointer()] = s.creationElement
@@ -481,9 +546,7 @@ func (s *stage) sceneMousePressEvent(event *widgets.QGraphicsSceneMouseEvent) {
 func (s *stage) sceneMouseReleaseEvent(event *widgets.QGraphicsSceneMouseEvent) {
 	if s.creationElement != nil {
 		// an element is currently being created
-		s.projectScene.Elements = append(s.projectScene.Elements, s.creationElement.element)
-		// we do this to get the new correct reference to the element in the slice because element is copied
-		s.creationElement.element = s.projectScene.Elements[len(s.projectScene.Elements)-1]
+		s.projectScene.AddElement(s.creationElement.element)
 		s.creationElement = nil
 		s.editor.userActions.cursor.Toggle() // switch the tool back to the standard cursor
 	}
@@ -761,8 +824,14 @@ func (s *stage) drawForeground(painter *gui.QPainter, rect *core.QRectF) {
 	// scanner preview
 	painter.SetPen(noPen)
 	gradient := gui.NewQLinearGradient2(gradientStart, needleStart)
-	for i, pixel := range s.needlePipeline.LastFrame.Pixels {
-		pixelPosition, pixelWidth := s.needlePipeline.Scanner.GetPixelPosition(i)
+	var previewPixels []color.RGBA64
+	preview := s.previewOutput()
+	if preview != nil {
+		preview.LastFrameInto(&s.previewFrame)
+		previewPixels = s.previewFrame.Pixels
+	}
+	for i, pixel := range previewPixels {
+		pixelPosition, pixelWidth := preview.Scanner.GetPixelPosition(i)
 		if pixelPosition < 0 || pixelPosition > 1 {
 			continue
 		}
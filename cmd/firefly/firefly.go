@@ -8,6 +8,7 @@ import (
 	"runtime"
 
 	"github.com/omniskop/firefly/pkg/scanner"
+	"github.com/omniskop/firefly/pkg/streamer"
 
 	"github.com/omniskop/firefly/cmd/firefly/settings"
 
@@ -81,7 +82,31 @@ func versionCheck() {
 		settings.Set("audio/newProjectAudioCopy", "audioSources")
 		fallthrough
 	case "0.1.3":
+		// the single live LED strip has been replaced by a list of outputs
+		outputs, _ := json.Marshal([]streamer.OutputConfig{legacyOutputConfig()})
+		settings.Set("liveLedStrip/outputs", string(outputs))
+		for _, key := range []string{"address", "port", "mapping"} {
+			settings.Remove("liveLedStrip/" + key)
+		}
+		settings.Set("liveLedStrip/frameRate", streamer.DefaultFrameRate)
+		fallthrough
+	case "0.1.4":
 	}
 
-	settings.Set("version", "0.1.3")
+	settings.Set("version", "0.1.4")
+}
+
+// legacyOutputConfig converts the settings of the single live LED strip of previous versions into an output.
+// Those versions only streamed with the WLED protocol, which is the default of an output.
+func legacyOutputConfig() streamer.OutputConfig {
+	output := streamer.DefaultOutputConfig()
+	output.Enabled = true // previous versions only had the global switch
+	output.Address = settings.GetString("liveLedStrip/address")
+	output.Port = settings.GetInt("liveLedStrip/port")
+
+	var mapping scanner.Mapping
+	if json.Unmarshal([]byte(settings.GetString("liveLedStrip/mapping")), &mapping) == nil && len(mapping.Segments) > 0 {
+		output.Mapping = mapping
+	}
+	return output
 }
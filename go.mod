@@ -5,10 +5,10 @@ go 1.17
 require (
 	github.com/sirupsen/logrus v1.6.0
 	github.com/therecipe/qt v0.0.0-20200126204426-5074eb6d8c41
+	golang.org/x/sys v0.1.0
 )
 
 require (
 	github.com/gopherjs/gopherjs v0.0.0-20200217142428-fce0ec30dd00 // indirect
 	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
-	golang.org/x/sys v0.1.0 // indirect
 )
@@ -0,0 +1,95 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// BasicDecoder decodes both versions of the streamer.BasicStreamer.
+// Version 0 contains a whole frame in a single packet. Version 1 splits a frame into packets where the first one
+// contains the total number of pixels. Packets that arrive before the first one of a frame are ignored.
+type BasicDecoder struct {
+	layout   *layout
+	pixels   []color.RGBA64
+	received int // the number of pixels of the current version 1 frame that have been received
+	total    int // the number of pixels of the current version 1 frame or -1 if no frame has been started
+}
+
+func NewBasic(l Layout) (*BasicDecoder, error) {
+	prepared, err := newLayout(l, 2.2)
+	if err != nil {
+		return nil, err
+	}
+	return &BasicDecoder{layout: prepared, total: -1}, nil
+}
+
+func (d *BasicDecoder) Decode(packet []byte) (scanner.Frame, bool, error) {
+	if len(packet) == 0 {
+		return scanner.Frame{}, false, errors.New("basic: empty packet")
+	}
+	switch packet[0] {
+	case 0:
+		return d.decodeVersion0(packet[1:])
+	case 1:
+		return d.decodeVersion1(packet[1:])
+	default:
+		return scanner.Frame{}, false, fmt.Errorf("basic: unknown packet type %d", packet[0])
+	}
+}
+
+func (d *BasicDecoder) decodeVersion0(data []byte) (scanner.Frame, bool, error) {
+	channels := d.layout.channels()
+	if len(data)%channels != 0 {
+		return scanner.Frame{}, false, fmt.Errorf("basic: %d bytes are not a multiple of %d channels", len(data), channels)
+	}
+	d.pixels = resizePixels(d.pixels, len(data)/channels)
+	for i := range d.pixels {
+		d.pixels[i] = d.layout.read(data[i*channels:], false)
+	}
+	return scanner.Frame{Pixels: d.pixels}, true, nil
+}
+
+func (d *BasicDecoder) decodeVersion1(data []byte) (scanner.Frame, bool, error) {
+	if len(data) < 1 || len(data) < 1+int(data[0])+4 {
+		return scanner.Frame{}, false, errors.New("basic: packet is too short")
+	}
+	header := data[1 : 1+data[0]]
+	data = data[1+len(header):]
+	if len(header) > 0 {
+		// the header is only contained in the first packet of a frame
+		if len(header) < 3 || header[0] != 0 {
+			return scanner.Frame{}, false, errors.New("basic: invalid header")
+		}
+		d.total = int(binary.LittleEndian.Uint16(header[1:]))
+		d.received = 0
+		d.pixels = resizePixels(d.pixels, d.total)
+	}
+	if d.total < 0 {
+		return scanner.Frame{}, false, nil
+	}
+
+	offset := int(binary.LittleEndian.Uint16(data[0:]))
+	length := int(binary.LittleEndian.Uint16(data[2:]))
+	data = data[4:]
+	channels := d.layout.channels()
+	if length != len(data) || length%channels != 0 {
+		return scanner.Frame{}, false, fmt.Errorf("basic: invalid data length %d", length)
+	}
+	count := length / channels
+	if offset+count > d.total {
+		return scanner.Frame{}, false, fmt.Errorf("basic: pixels %d to %d exceed the frame with %d pixels", offset, offset+count, d.total)
+	}
+	for i := 0; i < count; i++ {
+		d.pixels[offset+i] = d.layout.read(data[i*channels:], false)
+	}
+	d.received += count
+	if d.received < d.total {
+		return scanner.Frame{}, false, nil
+	}
+	d.total = -1
+	return scanner.Frame{Pixels: d.pixels}, true, nil
+}
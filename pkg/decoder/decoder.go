@@ -0,0 +1,155 @@
+// Package decoder parses the data of the streamers back into frames.
+// It is the counterpart of the streamer package and can be used to simulate devices.
+package decoder
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+	"github.com/omniskop/firefly/pkg/streamer"
+)
+
+// ProtocolGob is the format of the streamer.GobStreamer. It is not one of the streamer.Protocols because the
+// GobStreamer is not used for live outputs.
+const ProtocolGob streamer.Protocol = "gob"
+
+// A Decoder receives the packets of a streamer in the order in which they have been written.
+type Decoder interface {
+	// Decode parses the packet and returns true once a frame is complete.
+	// The pixels of the frame are reused and only valid until the next call.
+	Decode(packet []byte) (frame scanner.Frame, complete bool, err error)
+}
+
+// Layout describes how the streamer converted the colors of the pixels. It corresponds to the
+// streamer.Calibration without the corrections that can't be reverted.
+type Layout struct {
+	// ChannelOrder is the order of the channels of a pixel like "RGB" or "GRBW". An empty order is "RGB".
+	ChannelOrder string
+	// Gamma is the gamma that the streamer applied. A value of 0 uses the default gamma of the protocol.
+	Gamma float64
+}
+
+// New creates a decoder for the protocol. Only the BasicStreamer, WLEDStreamer and GobStreamer can be decoded.
+func New(protocol streamer.Protocol, layout Layout) (Decoder, error) {
+	switch protocol {
+	case streamer.ProtocolBasic:
+		return NewBasic(layout)
+	case streamer.ProtocolWLED:
+		return NewWLED(layout)
+	case ProtocolGob:
+		return NewGob(layout)
+	default:
+		return nil, fmt.Errorf("the protocol %q can't be decoded", protocol)
+	}
+}
+
+const (
+	channelRed = iota
+	channelGreen
+	channelBlue
+	channelWhite
+)
+
+// layout is a prepared Layout that converts the channels of a pixel back into a color
+type layout struct {
+	order []int // the channel of every byte of a pixel
+	table [0x100]uint16
+}
+
+func newLayout(l Layout, defaultGamma float64) (*layout, error) {
+	prepared := &layout{}
+	order := l.ChannelOrder
+	if order == "" {
+		order = "RGB"
+	}
+	seen := make(map[rune]bool)
+	for _, letter := range strings.ToUpper(order) {
+		if seen[letter] {
+			return nil, fmt.Errorf("channel order %q contains %c more than once", order, letter)
+		}
+		seen[letter] = true
+		switch letter {
+		case 'R':
+			prepared.order = append(prepared.order, channelRed)
+		case 'G':
+			prepared.order = append(prepared.order, channelGreen)
+		case 'B':
+			prepared.order = append(prepared.order, channelBlue)
+		case 'W':
+			prepared.order = append(prepared.order, channelWhite)
+		default:
+			return nil, fmt.Errorf("channel order %q contains unknown channel %c", order, letter)
+		}
+	}
+	if !seen['R'] || !seen['G'] || !seen['B'] {
+		return nil, fmt.Errorf("channel order %q needs to contain R, G and B", order)
+	}
+
+	gamma := l.Gamma
+	if gamma == 0 {
+		gamma = defaultGamma
+	}
+	if gamma < 0 || math.IsNaN(gamma) || math.IsInf(gamma, 0) {
+		return nil, fmt.Errorf("invalid gamma %v", gamma)
+	}
+	// invert the gamma table of the streamer
+	for i := range prepared.table {
+		prepared.table[i] = uint16(math.Round(math.Pow(float64(i)/0xff, 1/gamma) * 0xffff))
+	}
+	return prepared, nil
+}
+
+// channels returns the number of bytes per pixel
+func (l *layout) channels() int {
+	return len(l.order)
+}
+
+// read converts the channels of a pixel back into a color.
+// If rgbOnly is true the white channel is skipped like streamers do for devices that don't support one.
+func (l *layout) read(data []byte, rgbOnly bool) color.RGBA64 {
+	var values [4]uint32
+	n := 0
+	for _, channel := range l.order {
+		if rgbOnly && channel == channelWhite {
+			continue
+		}
+		values[channel] = uint32(l.table[data[n]])
+		n++
+	}
+	// the white component has been subtracted from the other channels
+	clamp := func(v uint32) uint16 {
+		if v > 0xffff {
+			return 0xffff
+		}
+		return uint16(v)
+	}
+	return color.RGBA64{
+		R: clamp(values[channelRed] + values[channelWhite]),
+		G: clamp(values[channelGreen] + values[channelWhite]),
+		B: clamp(values[channelBlue] + values[channelWhite]),
+		A: 0xffff,
+	}
+}
+
+// rgbChannels returns the number of bytes per pixel without the white channel
+func (l *layout) rgbChannels() int {
+	for _, channel := range l.order {
+		if channel == channelWhite {
+			return len(l.order) - 1
+		}
+	}
+	return len(l.order)
+}
+
+// resizePixels returns a slice with the length that reuses the memory of pixels if possible
+func resizePixels(pixels []color.RGBA64, length int) []color.RGBA64 {
+	if cap(pixels) < length {
+		grown := make([]color.RGBA64, length)
+		copy(grown, pixels)
+		return grown
+	}
+	return pixels[:length]
+}
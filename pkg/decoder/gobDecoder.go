@@ -0,0 +1,52 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// GobDecoder decodes the frames of the streamer.GobStreamer.
+// The GobStreamer sends the description of the frame type only once at the beginning. The decoder therefore needs
+// to receive all packets since the streamer has been created.
+type GobDecoder struct {
+	layout  *layout
+	buffer  bytes.Buffer
+	decoder *gob.Decoder
+	frame   scanner.Frame
+}
+
+func NewGob(l Layout) (*GobDecoder, error) {
+	prepared, err := newLayout(l, 2.2)
+	if err != nil {
+		return nil, err
+	}
+	d := &GobDecoder{layout: prepared}
+	d.decoder = gob.NewDecoder(&d.buffer)
+	return d, nil
+}
+
+// Decode parses a packet of the streamer. Every packet needs to contain complete gob messages which is the case for
+// the packets of the GobStreamer because it writes every message at once.
+func (d *GobDecoder) Decode(packet []byte) (scanner.Frame, bool, error) {
+	d.buffer.Write(packet)
+	d.frame.Pixels = d.frame.Pixels[:0]
+	err := d.decoder.Decode(&d.frame)
+	if err == io.EOF || (err == io.ErrUnexpectedEOF && d.buffer.Len() == 0) {
+		// the packet only contained type descriptions which gob reports as a missing value
+		return scanner.Frame{}, false, nil
+	} else if err != nil {
+		d.buffer.Reset()
+		return scanner.Frame{}, false, err
+	}
+
+	// the streamer expanded the calibrated 8 bit values back to 16 bit
+	for i, pixel := range d.frame.Pixels {
+		d.frame.Pixels[i].R = d.layout.table[pixel.R>>8]
+		d.frame.Pixels[i].G = d.layout.table[pixel.G>>8]
+		d.frame.Pixels[i].B = d.layout.table[pixel.B>>8]
+	}
+	return d.frame, true, nil
+}
@@ -0,0 +1,113 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+const (
+	wledProtocolDRGB  = 2
+	wledProtocolDRGBW = 3
+	wledProtocolDNRGB = 4
+
+	wledMaxDNRGBPixels = 489 // the maximum number of pixels in a DNRGB packet
+)
+
+// WLEDDecoder decodes the DRGB, DRGBW and DNRGB packets of the streamer.WLEDStreamer.
+// DNRGB packets don't contain the length of the frame. A frame ends with a packet that is not full or when a packet
+// starts at the first pixel again.
+type WLEDDecoder struct {
+	layout *layout
+	pixels []color.RGBA64
+	// Timeout is the timeout of the last packet in seconds
+	Timeout byte
+	end     int // the end of the pixels of the current DNRGB frame
+}
+
+// NewWLED creates a WLEDDecoder. WLED performs the gamma correction itself so the default gamma is 1.
+func NewWLED(l Layout) (*WLEDDecoder, error) {
+	prepared, err := newLayout(l, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &WLEDDecoder{layout: prepared}, nil
+}
+
+func (d *WLEDDecoder) Decode(packet []byte) (scanner.Frame, bool, error) {
+	if len(packet) < 2 {
+		return scanner.Frame{}, false, errors.New("wled: packet is too short")
+	}
+	d.Timeout = packet[1]
+	switch packet[0] {
+	case wledProtocolDRGB:
+		return d.decodeDRGB(packet[2:], false)
+	case wledProtocolDRGBW:
+		return d.decodeDRGB(packet[2:], true)
+	case wledProtocolDNRGB:
+		return d.decodeDNRGB(packet[2:])
+	default:
+		return scanner.Frame{}, false, fmt.Errorf("wled: unsupported protocol %d", packet[0])
+	}
+}
+
+func (d *WLEDDecoder) decodeDRGB(data []byte, white bool) (scanner.Frame, bool, error) {
+	channels := d.layout.rgbChannels()
+	if white {
+		if channels == d.layout.channels() {
+			return scanner.Frame{}, false, errors.New("wled: received DRGBW but the channel order has no white channel")
+		}
+		channels = d.layout.channels()
+	}
+	if len(data)%channels != 0 {
+		return scanner.Frame{}, false, fmt.Errorf("wled: %d bytes are not a multiple of %d channels", len(data), channels)
+	}
+	d.end = 0
+	d.pixels = resizePixels(d.pixels, len(data)/channels)
+	for i := range d.pixels {
+		d.pixels[i] = d.layout.read(data[i*channels:], !white)
+	}
+	return scanner.Frame{Pixels: d.pixels}, true, nil
+}
+
+func (d *WLEDDecoder) decodeDNRGB(data []byte) (scanner.Frame, bool, error) {
+	if len(data) < 2 {
+		return scanner.Frame{}, false, errors.New("wled: packet is too short")
+	}
+	start := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	channels := d.layout.rgbChannels()
+	if len(data)%channels != 0 {
+		return scanner.Frame{}, false, fmt.Errorf("wled: %d bytes are not a multiple of %d channels", len(data), channels)
+	}
+	count := len(data) / channels
+
+	var previous []color.RGBA64
+	if start == 0 && d.end > 0 {
+		// the previous frame ended with a full packet
+		previous = append([]color.RGBA64(nil), d.pixels[:d.end]...)
+	}
+	if start == 0 {
+		d.end = 0
+	}
+	d.pixels = resizePixels(d.pixels, start+count)
+	for i := 0; i < count; i++ {
+		d.pixels[start+i] = d.layout.read(data[i*channels:], true)
+	}
+	if start+count > d.end {
+		d.end = start + count
+	}
+
+	if previous != nil {
+		return scanner.Frame{Pixels: previous}, true, nil
+	}
+	if count < wledMaxDNRGBPixels {
+		end := d.end
+		d.end = 0
+		return scanner.Frame{Pixels: d.pixels[:end]}, true, nil
+	}
+	return scanner.Frame{}, false, nil
+}
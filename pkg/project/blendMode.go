@@ -0,0 +1,56 @@
+package project
+
+import "fmt"
+
+// BlendMode describes how the colors of an element are combined with the colors of the elements below it
+type BlendMode int
+
+const (
+	BlendNormal   BlendMode = iota // the element is drawn on top of the elements below it
+	BlendAdd                       // the colors are added together like overlapping light beams
+	BlendMultiply                  // the colors are multiplied which darkens the result
+	BlendScreen                    // the inverted colors are multiplied which brightens the result
+	BlendLighten                   // the maximum of both colors is used
+	BlendDarken                    // the minimum of both colors is used
+	BlendSubtract                  // the color of the element is subtracted from the colors below it
+)
+
+// BlendModes contains all available blend modes
+var BlendModes = []BlendMode{BlendNormal, BlendAdd, BlendMultiply, BlendScreen, BlendLighten, BlendDarken, BlendSubtract}
+
+var blendModeNames = map[BlendMode]string{
+	BlendNormal:   "normal",
+	BlendAdd:      "add",
+	BlendMultiply: "multiply",
+	BlendScreen:   "screen",
+	BlendLighten:  "lighten",
+	BlendDarken:   "darken",
+	BlendSubtract: "subtract",
+}
+
+// String returns the name of the blend mode
+func (m BlendMode) String() string {
+	if name, ok := blendModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("BlendMode(%d)", int(m))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (m BlendMode) MarshalText() ([]byte, error) {
+	if _, ok := blendModeNames[m]; !ok {
+		return nil, fmt.Errorf("unknown blend mode %d", int(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (m *BlendMode) UnmarshalText(text []byte) error {
+	for mode, name := range blendModeNames {
+		if name == string(text) {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown blend mode %q", text)
+}
@@ -0,0 +1,248 @@
+package project
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// ColorInterpolation describes the color space in which a gradient interpolates between its colors
+type ColorInterpolation int
+
+const (
+	InterpolateSRGB      ColorInterpolation = iota // the sRGB channels are interpolated directly
+	InterpolateLinearRGB                           // the channels are interpolated in linear light
+	InterpolateHSVShort                            // the hue takes the shorter path around the color wheel
+	InterpolateHSVLong                             // the hue takes the longer path around the color wheel
+	InterpolateOKLab                               // a perceptual color space that produces even transitions
+)
+
+// ColorInterpolations contains all available color interpolations
+var ColorInterpolations = []ColorInterpolation{InterpolateSRGB, InterpolateLinearRGB, InterpolateHSVShort, InterpolateHSVLong, InterpolateOKLab}
+
+var colorInterpolationNames = map[ColorInterpolation]string{
+	InterpolateSRGB:      "srgb",
+	InterpolateLinearRGB: "linear",
+	InterpolateHSVShort:  "hsv",
+	InterpolateHSVLong:   "hsv-long",
+	InterpolateOKLab:     "oklab",
+}
+
+// String returns the name of the color interpolation
+func (i ColorInterpolation) String() string {
+	if name, ok := colorInterpolationNames[i]; ok {
+		return name
+	}
+	return fmt.Sprintf("ColorInterpolation(%d)", int(i))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (i ColorInterpolation) MarshalText() ([]byte, error) {
+	if _, ok := colorInterpolationNames[i]; !ok {
+		return nil, fmt.Errorf("unknown color interpolation %d", int(i))
+	}
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (i *ColorInterpolation) UnmarshalText(text []byte) error {
+	for interpolation, name := range colorInterpolationNames {
+		if name == string(text) {
+			*i = interpolation
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown color interpolation %q", text)
+}
+
+// Interpolate returns the color between colorA and colorB at the progress in the color space of the interpolation.
+// The alpha channel is always interpolated linearly. The other components are weighted by the alpha of their color,
+// so a transparent color doesn't darken the other one. progress gets clamped between 0 and 1.
+func (i ColorInterpolation) Interpolate(colorA color.Color, colorB color.Color, progress float64) color.Color {
+	progress = math.Min(1, math.Max(0, progress))
+	a := newFloatColor(colorA)
+	b := newFloatColor(colorB)
+	alpha := lerp(a.a, b.a, progress)
+	// mix interpolates a component of both colors premultiplied with their alpha and returns it with straight alpha
+	mix := func(x, y float64) float64 {
+		if alpha == 0 {
+			return lerp(x, y, progress)
+		}
+		return lerp(x*a.a, y*b.a, progress) / alpha
+	}
+
+	var r, g, bl float64
+	switch i {
+	case InterpolateLinearRGB:
+		r = linearToSRGB(mix(sRGBToLinear(a.r), sRGBToLinear(b.r)))
+		g = linearToSRGB(mix(sRGBToLinear(a.g), sRGBToLinear(b.g)))
+		bl = linearToSRGB(mix(sRGBToLinear(a.b), sRGBToLinear(b.b)))
+	case InterpolateHSVShort, InterpolateHSVLong:
+		r, g, bl = interpolateHSV(a, b, progress, i == InterpolateHSVLong, mix)
+	case InterpolateOKLab:
+		aL, aA, aB := linearToOKLab(sRGBToLinear(a.r), sRGBToLinear(a.g), sRGBToLinear(a.b))
+		bL, bA, bB := linearToOKLab(sRGBToLinear(b.r), sRGBToLinear(b.g), sRGBToLinear(b.b))
+		lr, lg, lb := okLabToLinear(mix(aL, bL), mix(aA, bA), mix(aB, bB))
+		r, g, bl = linearToSRGB(lr), linearToSRGB(lg), linearToSRGB(lb)
+	default:
+		r, g, bl = mix(a.r, b.r), mix(a.g, b.g), mix(a.b, b.b)
+	}
+
+	return floatColor{r: r, g: g, b: bl, a: alpha}.rgba64()
+}
+
+// floatColor is a color with straight alpha whose components are in the range of [0, 1]
+type floatColor struct {
+	r, g, b, a float64
+}
+
+// newFloatColor converts the alpha-premultiplied color into a floatColor with straight alpha
+func newFloatColor(c color.Color) floatColor {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return floatColor{}
+	}
+	return floatColor{r: float64(r) / float64(a), g: float64(g) / float64(a), b: float64(b) / float64(a), a: float64(a) / 0xffff}
+}
+
+// rgba64 returns the color premultiplied with its alpha like all colors of the image/color package
+func (c floatColor) rgba64() color.RGBA64 {
+	channel := func(v float64) uint16 {
+		return uint16(math.Round(math.Min(1, math.Max(0, v)) * 0xffff))
+	}
+	alpha := math.Min(1, math.Max(0, c.a))
+	return color.RGBA64{R: channel(c.r * alpha), G: channel(c.g * alpha), B: channel(c.b * alpha), A: channel(alpha)}
+}
+
+func lerp(a, b, progress float64) float64 {
+	return a + (b-a)*progress
+}
+
+// sRGBToLinear converts an sRGB encoded channel to linear light
+func sRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a channel in linear light to the sRGB encoding
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// interpolateHSV interpolates between the colors a and b in the HSV color space.
+// If long is true the hue takes the longer way around the color wheel. The saturation and value are interpolated
+// with mix while the hue is interpolated directly.
+func interpolateHSV(a, b floatColor, progress float64, long bool, mix func(x, y float64) float64) (float64, float64, float64) {
+	aH, aS, aV := rgbToHSV(a.r, a.g, a.b)
+	bH, bS, bV := rgbToHSV(b.r, b.g, b.b)
+
+	// colors without saturation don't have a meaningful hue, so they take the hue of the other color
+	hueless := aS == 0 || bS == 0
+	if aS == 0 {
+		aH = bH
+	}
+	if bS == 0 {
+		bH = aH
+	}
+
+	difference := bH - aH
+	if !long {
+		if difference > 180 {
+			difference -= 360
+		} else if difference < -180 {
+			difference += 360
+		}
+	} else if difference == 0 {
+		// the long way between two colors of the same hue is a full turn around the color wheel
+		if !hueless {
+			difference = 360
+		}
+	} else if difference > 0 && difference < 180 {
+		difference -= 360
+	} else if difference < 0 && difference > -180 {
+		difference += 360
+	}
+	hue := math.Mod(aH+difference*progress+360, 360)
+
+	return hsvToRGB(hue, mix(aS, bS), mix(aV, bV))
+}
+
+// rgbToHSV returns the hue in degrees [0, 360[ and the saturation and value in the range of [0, 1]
+func rgbToHSV(r, g, b float64) (float64, float64, float64) {
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+
+	var hue float64
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == r:
+		hue = 60 * math.Mod((g-b)/delta, 6)
+	case max == g:
+		hue = 60 * ((b-r)/delta + 2)
+	default:
+		hue = 60 * ((r-g)/delta + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+
+	var saturation float64
+	if max > 0 {
+		saturation = delta / max
+	}
+	return hue, saturation, max
+}
+
+func hsvToRGB(h, s, v float64) (float64, float64, float64) {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// linearToOKLab converts a color in linear sRGB to OKLab.
+// See https://bottosson.github.io/posts/oklab/
+func linearToOKLab(r, g, b float64) (float64, float64, float64) {
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+}
+
+// okLabToLinear converts a color in OKLab to linear sRGB
+func okLabToLinear(L, a, b float64) (float64, float64, float64) {
+	l := L + 0.3963377774*a + 0.2158037573*b
+	m := L - 0.1055613458*a - 0.0638541728*b
+	s := L - 0.0894841775*a - 1.2914855480*b
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
@@ -9,9 +9,10 @@ import (
 
 // An Element that is located at a specific point in time in the scene
 type Element struct {
-	ZIndex  float64     // a coordinate relative to other elements in the scene. Higher numbers will be drawn on top of lower ones
-	Shape   shape.Shape // the actual visual shape of the element
-	Pattern Pattern     // the pattern that fills the body of the element
+	ZIndex    float64     // a coordinate relative to other elements in the scene. Higher numbers will be drawn on top of lower ones
+	Shape     shape.Shape // the actual visual shape of the element
+	Pattern   Pattern     // the pattern that fills the body of the element
+	BlendMode BlendMode   // how the element is combined with the elements below it
 }
 
 // MapLocalToRelative maps local coordinates to relative coordinates.
@@ -44,9 +45,10 @@ func (e *Element) MapRelativeToLocal(relative vectorpath.Point) vectorpath.Point
 // Copy returns a deep copy of the element
 func (e *Element) Copy() *Element {
 	return &Element{
-		ZIndex:  e.ZIndex,
-		Shape:   e.Shape.Copy(),
-		Pattern: e.Pattern.Copy(),
+		ZIndex:    e.ZIndex,
+		Shape:     e.Shape.Copy(),
+		Pattern:   e.Pattern.Copy(),
+		BlendMode: e.BlendMode,
 	}
 }
 
@@ -83,5 +85,14 @@ func (e *Element) UnmarshalJSON(data []byte) error {
 	e.Pattern = pattern
 
 	err = json.Unmarshal(*values["ZIndex"], &e.ZIndex)
+	if err != nil {
+		return err
+	}
+
+	// projects that have been created before blend modes existed don't contain this key
+	e.BlendMode = BlendNormal
+	if rawBlendMode, ok := values["BlendMode"]; ok {
+		err = json.Unmarshal(*rawBlendMode, &e.BlendMode)
+	}
 	return err
 }
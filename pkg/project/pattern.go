@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"image/color"
+	"math"
 
 	"github.com/omniskop/firefly/pkg/project/vectorpath"
 )
@@ -29,6 +30,20 @@ type Pattern interface {
 	Copy() Pattern
 }
 
+// Gradient is a pattern that fades between the colors of two anchor points and optional color steps in between
+type Gradient interface {
+	Pattern
+
+	Anchors() (*GradientAnchorPoint, *GradientAnchorPoint) // returns the anchor points at the progress 0 and 1
+	ColorSteps() []GradientColorStep
+	Progress(point vectorpath.Point) float64 // returns the progress of the gradient at a point in local coordinates
+	ColorAt(progress float64) color.Color
+	AddStep(position float64) int
+	RemoveStep(index int)
+	ColorInterpolation() ColorInterpolation
+	SetColorInterpolation(interpolation ColorInterpolation)
+}
+
 func UnmarshalPattern(raw []byte) (Pattern, error) {
 	values := make(map[string]*json.RawMessage)
 	err := json.Unmarshal(raw, &values)
@@ -58,6 +73,10 @@ func UnmarshalPattern(raw []byte) (Pattern, error) {
 		data := new(LinearGradient)
 		err = json.Unmarshal(*values["Pattern"], data)
 		return data, err
+	case "RadialGradient":
+		data := new(RadialGradient)
+		err = json.Unmarshal(*values["Pattern"], data)
+		return data, err
 	default:
 		// I considered using a json.UnmarshalTypeError but decided against it because it has a bunch of field that
 		// i would not fill and it would probably end up less descriptive than just a simple error.
@@ -130,12 +149,13 @@ func (c *SolidColor) UnmarshalJSON(raw []byte) error {
 // The positions of the gradient are in local coordinates to the element,
 // meaning that the top left position is (0,0) and the bottom right one is at (1,1).
 type LinearGradient struct {
-	Start GradientAnchorPoint
-	Stop  GradientAnchorPoint
-	Steps []GradientColorStep // the steps between the anchor points
+	Start         GradientAnchorPoint
+	Stop          GradientAnchorPoint
+	Steps         []GradientColorStep // the steps between the anchor points
+	Interpolation ColorInterpolation  // the color space in which the colors are interpolated
 }
 
-var _ Pattern = (*LinearGradient)(nil) // make sure LinearGradient implements the Pattern interface
+var _ Gradient = (*LinearGradient)(nil) // make sure LinearGradient implements the Gradient interface
 
 // NewLinearGradient creates a new LinearGradient with the given start and stop colors
 func NewLinearGradient(a color.Color, b color.Color) *LinearGradient {
@@ -165,19 +185,202 @@ func (g *LinearGradient) MirrorP() {
 
 func (g *LinearGradient) Copy() Pattern {
 	return &LinearGradient{
-		Start: g.Start.Copy(),
-		Stop:  g.Stop.Copy(),
-		Steps: nil,
+		Start:         g.Start.Copy(),
+		Stop:          g.Stop.Copy(),
+		Steps:         copyGradientSteps(g.Steps),
+		Interpolation: g.Interpolation,
+	}
+}
+
+// Anchors returns the start and stop anchor points of the gradient
+func (g *LinearGradient) Anchors() (*GradientAnchorPoint, *GradientAnchorPoint) {
+	return &g.Start, &g.Stop
+}
+
+// ColorSteps returns the color steps between the anchor points
+func (g *LinearGradient) ColorSteps() []GradientColorStep {
+	return g.Steps
+}
+
+// Progress returns how far the point in local coordinates has progressed from the start to the stop of the gradient.
+// The point is projected onto the line between the anchor points and the result is 0 at the start and 1 at the stop.
+func (g *LinearGradient) Progress(point vectorpath.Point) float64 {
+	toPoint := point.Sub(g.Start.Point)                              // vector from the start of the gradient to the point of interest
+	gradTrack := g.Stop.Point.Sub(g.Start.Point)                     // vector from start to end of the gradient
+	trackLength := gradTrack.P*gradTrack.P + gradTrack.T*gradTrack.T // squared length of the gradient
+	if trackLength == 0 {
+		return 0
 	}
+	return (toPoint.P*gradTrack.P + toPoint.T*gradTrack.T) / trackLength
+}
+
+// ColorAt returns the color of the gradient at the progress between the start (0) and the stop (1).
+// The progress gets clamped between 0 and 1.
+func (g *LinearGradient) ColorAt(progress float64) color.Color {
+	return gradientColorAt(g.Start.Color, g.Stop.Color, g.Steps, g.Interpolation, progress)
+}
+
+// ColorInterpolation returns the color space in which the colors of the gradient are interpolated
+func (g *LinearGradient) ColorInterpolation() ColorInterpolation {
+	return g.Interpolation
+}
+
+// SetColorInterpolation sets the color space in which the colors of the gradient are interpolated
+func (g *LinearGradient) SetColorInterpolation(interpolation ColorInterpolation) {
+	g.Interpolation = interpolation
+}
+
+// AddStep inserts a new color step at the position with the color that the gradient currently has there.
+// It returns the index of the new step.
+func (g *LinearGradient) AddStep(position float64) int {
+	return addGradientStep(&g.Steps, g, position)
+}
+
+// RemoveStep removes the color step with the index
+func (g *LinearGradient) RemoveStep(index int) {
+	removeGradientStep(&g.Steps, index)
 }
 
 func (g *LinearGradient) MarshalJSON() ([]byte, error) {
 	var values = map[string]interface{}{
 		"__TYPE__": "LinearGradient",
 		"Pattern": map[string]interface{}{
-			"Start": g.Start,
-			"Stop":  g.Stop,
-			"Steps": g.Steps,
+			"Start":         g.Start,
+			"Stop":          g.Stop,
+			"Steps":         g.Steps,
+			"Interpolation": g.Interpolation,
+		},
+	}
+	return json.Marshal(values)
+}
+
+// RadialGradient fills an element with a gradient that fades outward from a center point.
+// The distance between the center and the edge defines the radius of the gradient.
+// The AspectRatio stretches the falloff along the time axis relative to the position axis,
+// a value of 2 for example makes the gradient reach twice as far in time as it does in position.
+// Like with the LinearGradient the positions are in local coordinates to the element.
+type RadialGradient struct {
+	Center        GradientAnchorPoint
+	Edge          GradientAnchorPoint
+	AspectRatio   float64             // the ratio of the radius on the time axis to the radius on the position axis
+	Steps         []GradientColorStep // the steps between the center and the edge
+	Interpolation ColorInterpolation  // the color space in which the colors are interpolated
+}
+
+var _ Gradient = (*RadialGradient)(nil) // make sure RadialGradient implements the Gradient interface
+
+// NewRadialGradient creates a new RadialGradient with the given center and edge colors
+func NewRadialGradient(center color.Color, edge color.Color) *RadialGradient {
+	// like the linear gradient this is placed slightly off center to not obstruct potential element handles
+	return &RadialGradient{
+		Center: GradientAnchorPoint{
+			Color: center,
+			Point: vectorpath.Point{P: 0.5, T: 0.4},
+		},
+		Edge: GradientAnchorPoint{
+			Color: edge,
+			Point: vectorpath.Point{P: 0.9, T: 0.4},
+		},
+		AspectRatio: 1,
+		Steps:       nil,
+	}
+}
+
+// Pattern implements the Pattern interface
+func (g *RadialGradient) Pattern() Pattern {
+	return g
+}
+
+func (g *RadialGradient) MirrorP() {
+	g.Center.Point.P = 1 - g.Center.Point.P
+	g.Edge.Point.P = 1 - g.Edge.Point.P
+}
+
+func (g *RadialGradient) Copy() Pattern {
+	return &RadialGradient{
+		Center:        g.Center.Copy(),
+		Edge:          g.Edge.Copy(),
+		AspectRatio:   g.AspectRatio,
+		Steps:         copyGradientSteps(g.Steps),
+		Interpolation: g.Interpolation,
+	}
+}
+
+// Anchors returns the center and edge anchor points of the gradient
+func (g *RadialGradient) Anchors() (*GradientAnchorPoint, *GradientAnchorPoint) {
+	return &g.Center, &g.Edge
+}
+
+// ColorSteps returns the color steps between the anchor points
+func (g *RadialGradient) ColorSteps() []GradientColorStep {
+	return g.Steps
+}
+
+// Aspect returns the aspect ratio of the gradient. Values that are not positive are treated as 1.
+func (g *RadialGradient) Aspect() float64 {
+	if g.AspectRatio <= 0 {
+		return 1
+	}
+	return g.AspectRatio
+}
+
+// Radius returns the radius of the gradient on the position axis.
+// The radius on the time axis is this value multiplied by the aspect ratio.
+func (g *RadialGradient) Radius() float64 {
+	return g.distance(g.Edge.Point.Sub(g.Center.Point))
+}
+
+// Progress returns how far the point in local coordinates is away from the center relative to the radius.
+// The result is 0 at the center and 1 on the ellipse that goes through the edge.
+func (g *RadialGradient) Progress(point vectorpath.Point) float64 {
+	radius := g.Radius()
+	if radius == 0 {
+		return 0
+	}
+	return g.distance(point.Sub(g.Center.Point)) / radius
+}
+
+// distance returns the length of the vector after the time axis has been scaled by the aspect ratio
+func (g *RadialGradient) distance(vector vectorpath.Point) float64 {
+	return math.Hypot(vector.P, vector.T/g.Aspect())
+}
+
+// ColorAt returns the color of the gradient at the progress between the center (0) and the edge (1).
+// The progress gets clamped between 0 and 1.
+func (g *RadialGradient) ColorAt(progress float64) color.Color {
+	return gradientColorAt(g.Center.Color, g.Edge.Color, g.Steps, g.Interpolation, progress)
+}
+
+// ColorInterpolation returns the color space in which the colors of the gradient are interpolated
+func (g *RadialGradient) ColorInterpolation() ColorInterpolation {
+	return g.Interpolation
+}
+
+// SetColorInterpolation sets the color space in which the colors of the gradient are interpolated
+func (g *RadialGradient) SetColorInterpolation(interpolation ColorInterpolation) {
+	g.Interpolation = interpolation
+}
+
+// AddStep inserts a new color step at the position with the color that the gradient currently has there.
+// It returns the index of the new step.
+func (g *RadialGradient) AddStep(position float64) int {
+	return addGradientStep(&g.Steps, g, position)
+}
+
+// RemoveStep removes the color step with the index
+func (g *RadialGradient) RemoveStep(index int) {
+	removeGradientStep(&g.Steps, index)
+}
+
+func (g *RadialGradient) MarshalJSON() ([]byte, error) {
+	var values = map[string]interface{}{
+		"__TYPE__": "RadialGradient",
+		"Pattern": map[string]interface{}{
+			"Center":        g.Center,
+			"Edge":          g.Edge,
+			"AspectRatio":   g.AspectRatio,
+			"Steps":         g.Steps,
+			"Interpolation": g.Interpolation,
 		},
 	}
 	return json.Marshal(values)
@@ -236,7 +439,15 @@ func (p *GradientAnchorPoint) UnmarshalJSON(raw []byte) error {
 // GradientColorStep is a position on a gradient that has a specific color
 type GradientColorStep struct {
 	color.Color
-	Position float64
+	Position float64 // the position between the start (0) and the stop (1) of the gradient
+}
+
+func (s GradientColorStep) Copy() GradientColorStep {
+	r, g, b, a := s.Color.RGBA()
+	return GradientColorStep{
+		Color:    color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)},
+		Position: s.Position,
+	}
 }
 
 func (s GradientColorStep) MarshalJSON() ([]byte, error) {
@@ -274,3 +485,55 @@ func (s *GradientColorStep) UnmarshalJSON(raw []byte) error {
 
 	return nil
 }
+
+// gradientColorAt returns the color of a gradient with the start and stop colors and additional color steps at the progress.
+// The colors are interpolated with the interpolation.
+// The steps don't need to be sorted. The progress gets clamped between 0 and 1.
+func gradientColorAt(start color.Color, stop color.Color, steps []GradientColorStep, interpolation ColorInterpolation, progress float64) color.Color {
+	progress = vectorpath.Clamp(progress, 0, 1)
+
+	// find the closest color stops before and after the progress
+	lowerPosition, lowerColor := 0.0, start
+	upperPosition, upperColor := 1.0, stop
+	for _, step := range steps {
+		if step.Position <= progress && step.Position >= lowerPosition {
+			lowerPosition, lowerColor = step.Position, step.Color
+		}
+		if step.Position >= progress && step.Position < upperPosition {
+			upperPosition, upperColor = step.Position, step.Color
+		}
+	}
+
+	if upperPosition <= lowerPosition {
+		return lowerColor
+	}
+	return interpolation.Interpolate(lowerColor, upperColor, (progress-lowerPosition)/(upperPosition-lowerPosition))
+}
+
+// copyGradientSteps returns a deep copy of the color steps of a gradient
+func copyGradientSteps(steps []GradientColorStep) []GradientColorStep {
+	if steps == nil {
+		return nil
+	}
+	copied := make([]GradientColorStep, len(steps))
+	for i, step := range steps {
+		copied[i] = step.Copy()
+	}
+	return copied
+}
+
+// addGradientStep appends a color step at the position with the color that the gradient currently has there.
+// It returns the index of the new step.
+func addGradientStep(steps *[]GradientColorStep, gradient Gradient, position float64) int {
+	position = vectorpath.Clamp(position, 0, 1)
+	*steps = append(*steps, GradientColorStep{Color: gradient.ColorAt(position), Position: position})
+	return len(*steps) - 1
+}
+
+// removeGradientStep removes the color step with the index, invalid indices are ignored
+func removeGradientStep(steps *[]GradientColorStep, index int) {
+	if index < 0 || index >= len(*steps) {
+		return
+	}
+	*steps = append((*steps)[:index], (*steps)[index+1:]...)
+}
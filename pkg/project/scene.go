@@ -1,17 +1,106 @@
 package project
 
-// Scene contains all the visual elements of a project
+import "encoding/json"
+
+// Scene contains all the visual elements of a project.
+// To find elements quickly the scene keeps an index over the time ranges of its elements.
+// Elements are added and removed with AddElement and RemoveElement and UpdateElement needs to be called
+// after the shape of an element has been changed. Otherwise lookups may return outdated results.
 type Scene struct {
+	elements []*Element
+	Effects  []*Effect
+	index    *sceneIndex
+}
+
+// sceneJSON is the stored form of a Scene
+type sceneJSON struct {
 	Elements []*Element
 	Effects  []*Effect
 }
 
-func (s Scene) GetElementsAt(time float64) []*Element {
-	var out []*Element
-	for _, element := range s.Elements {
-		if element.Shape.Bounds().IncludesTime(time) {
-			out = append(out, element)
+func (s Scene) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sceneJSON{Elements: s.Elements(), Effects: s.Effects})
+}
+
+func (s *Scene) UnmarshalJSON(data []byte) error {
+	var stored sceneJSON
+	err := json.Unmarshal(data, &stored)
+	if err != nil {
+		return err
+	}
+	s.SetElements(stored.Elements)
+	s.Effects = stored.Effects
+	return nil
+}
+
+// Elements returns all elements of the scene in the order in which they have been added
+func (s *Scene) Elements() []*Element {
+	index := s.getIndex()
+	defer index.mutex.Unlock()
+	return append([]*Element(nil), s.elements...)
+}
+
+// SetElements replaces all elements of the scene
+func (s *Scene) SetElements(elements []*Element) {
+	index := s.getIndex()
+	defer index.mutex.Unlock()
+	s.elements = append([]*Element(nil), elements...)
+	index.rebuild(s.elements)
+}
+
+// AddElement adds the element to the scene
+func (s *Scene) AddElement(element *Element) {
+	index := s.getIndex()
+	defer index.mutex.Unlock()
+	s.elements = append(s.elements, element)
+	index.insert(element)
+}
+
+// RemoveElement removes the element from the scene. It returns false if the element was not part of the scene.
+func (s *Scene) RemoveElement(element *Element) bool {
+	index := s.getIndex()
+	defer index.mutex.Unlock()
+	for i := range s.elements {
+		if s.elements[i] == element {
+			copy(s.elements[i:], s.elements[i+1:])
+			s.elements[len(s.elements)-1] = nil
+			s.elements = s.elements[:len(s.elements)-1]
+			index.remove(element)
+			return true
 		}
 	}
+	return false
+}
+
+// UpdateElement needs to be called after the shape of an element in the scene has changed
+func (s *Scene) UpdateElement(element *Element) {
+	index := s.getIndex()
+	defer index.mutex.Unlock()
+	if _, ok := index.nodes[element]; ok {
+		index.insert(element)
+	}
+}
+
+// GetElementsAt returns all elements that are visible at the time ordered by their start time
+func (s *Scene) GetElementsAt(time float64) []*Element {
+	entries := s.LookupAt(time)
+	out := make([]*Element, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.Element
+	}
 	return out
 }
+
+// LookupAt returns all elements that are visible at the time together with their bounds and path.
+// The elements are ordered by their start time.
+func (s *Scene) LookupAt(time float64) []IndexedElement {
+	return s.AppendElementsAt(nil, time)
+}
+
+// AppendElementsAt works like LookupAt but appends the elements to out.
+// This allows reusing the memory of previous lookups.
+func (s *Scene) AppendElementsAt(out []IndexedElement, time float64) []IndexedElement {
+	index := s.getIndex()
+	defer index.mutex.Unlock()
+	return index.lookup(index.root, time, out)
+}
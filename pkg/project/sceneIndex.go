@@ -0,0 +1,198 @@
+package project
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/project/vectorpath"
+)
+
+// IndexedElement is an element together with the bounds and path of its shape at the time it was indexed
+type IndexedElement struct {
+	*Element
+	Bounds vectorpath.Rect
+	Path   vectorpath.Path
+}
+
+// sceneIndex is an interval tree over the time ranges of the elements in a scene.
+// It is implemented as a treap ordered by the start time of the elements where each node additionally stores the
+// latest end time in its subtree. This allows finding all elements at a point in time in logarithmic time.
+type sceneIndex struct {
+	mutex  sync.Mutex
+	root   *indexNode
+	nodes  map[*Element]*indexNode
+	nextID uint64
+}
+
+type indexNode struct {
+	entry    IndexedElement
+	start    float64
+	end      float64
+	id       uint64  // breaks ties between elements with the same start time
+	maxEnd   float64 // the latest end of all nodes in this subtree
+	priority uint32
+	left     *indexNode
+	right    *indexNode
+}
+
+// indexCreation guards the lazy creation of scene indices
+var indexCreation sync.Mutex
+
+// getIndex returns the index of the scene and creates it if it doesn't exist yet.
+// The elements of the scene can only be changed through the methods of the scene which keep the index up to date.
+// The mutex of the returned index is locked and needs to be unlocked by the caller.
+func (s *Scene) getIndex() *sceneIndex {
+	indexCreation.Lock()
+	if s.index == nil {
+		s.index = &sceneIndex{}
+		s.index.rebuild(s.elements)
+	}
+	index := s.index
+	indexCreation.Unlock()
+
+	index.mutex.Lock()
+	return index
+}
+
+func (index *sceneIndex) rebuild(elements []*Element) {
+	index.root = nil
+	index.nodes = make(map[*Element]*indexNode, len(elements))
+	for _, element := range elements {
+		index.insert(element)
+	}
+}
+
+func (index *sceneIndex) insert(element *Element) {
+	if _, ok := index.nodes[element]; ok {
+		index.remove(element)
+	}
+	bounds := element.Shape.Bounds()
+	node := &indexNode{
+		entry: IndexedElement{
+			Element: element,
+			Bounds:  bounds,
+			Path:    element.Shape.Path(),
+		},
+		start:    bounds.Location.T,
+		end:      bounds.Location.T + bounds.Dimensions.T,
+		id:       index.nextID,
+		priority: rand.Uint32(),
+	}
+	node.maxEnd = node.end
+	index.nextID++
+	index.nodes[element] = node
+	index.root = insertNode(index.root, node)
+}
+
+func (index *sceneIndex) remove(element *Element) {
+	node, ok := index.nodes[element]
+	if !ok {
+		return
+	}
+	delete(index.nodes, element)
+	index.root = removeNode(index.root, node)
+}
+
+// lookup appends all entries whose time range includes the time to out, ordered by their start time
+func (index *sceneIndex) lookup(node *indexNode, time float64, out []IndexedElement) []IndexedElement {
+	if node == nil || node.maxEnd <= time {
+		return out
+	}
+	out = index.lookup(node.left, time, out)
+	if node.start < time {
+		if node.end > time {
+			out = append(out, node.entry)
+		}
+		// all nodes on the right start after this one so they can only match if this one starts before the time
+		out = index.lookup(node.right, time, out)
+	}
+	return out
+}
+
+// less returns whether node a comes before node b in the tree
+func (a *indexNode) less(b *indexNode) bool {
+	if a.start != b.start {
+		return a.start < b.start
+	}
+	return a.id < b.id
+}
+
+// update recalculates the maxEnd of the node from its children
+func (n *indexNode) update() {
+	n.maxEnd = n.end
+	if n.left != nil && n.left.maxEnd > n.maxEnd {
+		n.maxEnd = n.left.maxEnd
+	}
+	if n.right != nil && n.right.maxEnd > n.maxEnd {
+		n.maxEnd = n.right.maxEnd
+	}
+}
+
+// splitNodes splits the tree into all nodes that come before the pivot and all other nodes
+func splitNodes(node *indexNode, pivot *indexNode) (*indexNode, *indexNode) {
+	if node == nil {
+		return nil, nil
+	}
+	if node.less(pivot) {
+		var right *indexNode
+		node.right, right = splitNodes(node.right, pivot)
+		node.update()
+		return node, right
+	}
+	var left *indexNode
+	left, node.left = splitNodes(node.left, pivot)
+	node.update()
+	return left, node
+}
+
+// mergeNodes merges two trees where all nodes of left come before the nodes of right
+func mergeNodes(left *indexNode, right *indexNode) *indexNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		left.right = mergeNodes(left.right, right)
+		left.update()
+		return left
+	}
+	right.left = mergeNodes(left, right.left)
+	right.update()
+	return right
+}
+
+func insertNode(root *indexNode, node *indexNode) *indexNode {
+	if root == nil {
+		return node
+	}
+	if node.priority > root.priority {
+		node.left, node.right = splitNodes(root, node)
+		node.update()
+		return node
+	}
+	if node.less(root) {
+		root.left = insertNode(root.left, node)
+	} else {
+		root.right = insertNode(root.right, node)
+	}
+	root.update()
+	return root
+}
+
+func removeNode(root *indexNode, node *indexNode) *indexNode {
+	if root == nil {
+		return nil
+	}
+	if root == node {
+		return mergeNodes(root.left, root.right)
+	}
+	if node.less(root) {
+		root.left = removeNode(root.left, node)
+	} else {
+		root.right = removeNode(root.right, node)
+	}
+	root.update()
+	return root
+}
@@ -113,8 +113,19 @@ func (r Rect) United(other Rect) Rect {
 type Path struct {
 	Start    Point // TODO: check if this can be removed
 	Segments []Segment
+	FillRule FillRule // the rule that decides which areas are inside of the path
 }
 
+// FillRule describes which parts of a path are considered to be inside of it
+type FillRule int
+
+const (
+	// EvenOddFill fills an area if a line from it to the outside crosses the path an odd number of times
+	EvenOddFill FillRule = iota
+	// NonZeroFill fills an area if the path winds around it a different number of times in each direction
+	NonZeroFill
+)
+
 // Duration returns the duration (length on the time axis) of the path
 func (p Path) Duration() float64 {
 	var oldest float64
@@ -0,0 +1,208 @@
+package scanner
+
+import (
+	"math"
+	"sort"
+
+	"github.com/omniskop/firefly/pkg/project/vectorpath"
+)
+
+// An edge is a position where a path crosses a point in time
+type edge struct {
+	position  float64
+	direction int // +1 if the path moves forward in time at the crossing, -1 if it moves backwards
+}
+
+// appendSegmentEdges appends all edges where the segment crosses the point in time to edges.
+// time and the positions of the edges are relative to the start of the segment.
+//
+// A point that lies exactly on the time counts as being after it. This prevents crossings at the border between two
+// segments from being counted twice and segments that just touch the time from returning an edge.
+func appendSegmentEdges(edges []edge, segment vectorpath.Segment, time float64) []edge {
+	switch obj := segment.(type) {
+	case *vectorpath.Line:
+		if crossesTime(0, obj.T, time) {
+			return append(edges, edge{position: obj.P * (time / obj.T), direction: direction(0, obj.T)})
+		}
+		return edges
+	case *vectorpath.QuadCurve:
+		// B(t) = 2(1-t)t*Control + t²*End
+		return appendCurveEdges(
+			edges,
+			polynomial{0, 2 * obj.Control.P, obj.End.P - 2*obj.Control.P, 0},
+			polynomial{0, 2 * obj.Control.T, obj.End.T - 2*obj.Control.T, 0},
+			time,
+		)
+	case *vectorpath.CubicCurve:
+		// B(t) = 3(1-t)²t*ControlA + 3(1-t)t²*ControlB + t³*End
+		return appendCurveEdges(
+			edges,
+			polynomial{0, 3 * obj.ControlA.P, 3*obj.ControlB.P - 6*obj.ControlA.P, obj.End.P - 3*obj.ControlB.P + 3*obj.ControlA.P},
+			polynomial{0, 3 * obj.ControlA.T, 3*obj.ControlB.T - 6*obj.ControlA.T, obj.End.T - 3*obj.ControlB.T + 3*obj.ControlA.T},
+			time,
+		)
+	}
+	return edges
+}
+
+// appendCurveEdges appends the edges where a curve that is described by the polynomials positionCurve and timeCurve
+// over the progress t ∈ [0, 1] crosses the time.
+//
+// The curve is split at the extrema of its time component into pieces that are monotonic in time.
+// Each of these pieces can cross the time at most once which makes it easy to apply the same rules as for lines
+// and to find the exact number of crossings even if the curve crosses the time multiple times.
+func appendCurveEdges(edges []edge, positionCurve, timeCurve polynomial, time float64) []edge {
+	splits := []float64{0}
+	for _, extremum := range timeCurve.derivative().roots() {
+		if extremum > 0 && extremum < 1 {
+			splits = append(splits, extremum)
+		}
+	}
+	splits = append(splits, 1)
+	sort.Float64s(splits)
+
+	for i := 0; i < len(splits)-1; i++ {
+		from, to := splits[i], splits[i+1]
+		if !crossesTime(timeCurve.at(from), timeCurve.at(to), time) {
+			continue
+		}
+		t := findProgressOfTime(timeCurve, time, from, to)
+		edges = append(edges, edge{
+			position:  positionCurve.at(t),
+			direction: direction(timeCurve.at(from), timeCurve.at(to)),
+		})
+	}
+	return edges
+}
+
+// findProgressOfTime returns the progress t ∈ [from, to] at which the timeCurve reaches the time.
+// The timeCurve needs to be monotonic in this range and has to cross the time.
+func findProgressOfTime(timeCurve polynomial, time float64, from float64, to float64) float64 {
+	const tolerance = 1e-9
+	shifted := timeCurve
+	shifted[0] -= time
+	for _, t := range shifted.roots() {
+		if t >= from-tolerance && t <= to+tolerance {
+			return vectorpath.Clamp(t, from, to)
+		}
+	}
+
+	// The analytical solution can miss roots due to floating point inaccuracies.
+	// Because the curve is monotonic in this range a bisection will always find the crossing.
+	rising := timeCurve.at(to) > timeCurve.at(from)
+	for i := 0; i < 64; i++ {
+		middle := (from + to) / 2
+		if (timeCurve.at(middle) < time) == rising {
+			from = middle
+		} else {
+			to = middle
+		}
+	}
+	return (from + to) / 2
+}
+
+// crossesTime returns true if a and b lie on different sides of the time.
+// A value that is equal to the time counts as being after it.
+func crossesTime(a, b, time float64) bool {
+	return (a <= time) != (b <= time)
+}
+
+// direction returns +1 if b is later in time than a and -1 otherwise
+func direction(a, b float64) int {
+	if b > a {
+		return 1
+	}
+	return -1
+}
+
+// polynomial of up to the third degree. The coefficients are in ascending order of the power of t.
+type polynomial [4]float64
+
+// at evaluates the polynomial at t
+func (p polynomial) at(t float64) float64 {
+	return ((p[3]*t+p[2])*t+p[1])*t + p[0]
+}
+
+// derivative returns the first derivative of the polynomial
+func (p polynomial) derivative() polynomial {
+	return polynomial{p[1], 2 * p[2], 3 * p[3], 0}
+}
+
+// roots returns all real values of t for which the polynomial evaluates to zero.
+// If the polynomial is zero everywhere no roots will be returned.
+func (p polynomial) roots() []float64 {
+	return solveCubic(p[3], p[2], p[1], p[0])
+}
+
+// isNegligible returns true if the coefficient a is so small in comparison to the others that it can be treated as zero
+func isNegligible(a float64, others ...float64) bool {
+	scale := 0.0
+	for _, o := range others {
+		scale = math.Max(scale, math.Abs(o))
+	}
+	return math.Abs(a) <= scale*1e-12
+}
+
+// solveCubic returns the real solutions of a*t³ + b*t² + c*t + d = 0.
+// Degenerate cubics are solved as quadratic or linear equations.
+func solveCubic(a, b, c, d float64) []float64 {
+	if isNegligible(a, b, c, d) {
+		return solveQuadratic(b, c, d)
+	}
+
+	// normalize to t³ + b*t² + c*t + d = 0
+	b, c, d = b/a, c/a, d/a
+	// substituting t = x - b/3 results in the depressed cubic x³ + p*x + q = 0
+	p := c - b*b/3
+	q := 2*b*b*b/27 - b*c/3 + d
+	offset := -b / 3
+
+	discriminant := q*q/4 + p*p*p/27
+	switch {
+	case math.Abs(discriminant) < 1e-14:
+		if math.Abs(p) < 1e-14 {
+			return []float64{offset} // triple root
+		}
+		// one single and one double root
+		u := math.Cbrt(-q / 2)
+		return []float64{2*u + offset, -u + offset}
+	case discriminant > 0:
+		// one real root (Cardano's formula)
+		sqrtDiscriminant := math.Sqrt(discriminant)
+		return []float64{math.Cbrt(-q/2+sqrtDiscriminant) + math.Cbrt(-q/2-sqrtDiscriminant) + offset}
+	default:
+		// three real roots (trigonometric solution)
+		r := 2 * math.Sqrt(-p/3)
+		phi := math.Acos(vectorpath.Clamp(3*q/(p*r), -1, 1)) / 3
+		return []float64{
+			r*math.Cos(phi) + offset,
+			r*math.Cos(phi-2*math.Pi/3) + offset,
+			r*math.Cos(phi-4*math.Pi/3) + offset,
+		}
+	}
+}
+
+// solveQuadratic returns the real solutions of a*t² + b*t + c = 0.
+// Degenerate quadratics are solved as linear equations.
+func solveQuadratic(a, b, c float64) []float64 {
+	if isNegligible(a, b, c) {
+		if isNegligible(b, c) {
+			return nil // constant
+		}
+		return []float64{-c / b}
+	}
+
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return nil
+	}
+	if discriminant == 0 {
+		return []float64{-b / (2 * a)}
+	}
+	// this form avoids the cancellation of -b + sqrt(discriminant)
+	q := -(b + math.Copysign(math.Sqrt(discriminant), b)) / 2
+	if q == 0 {
+		return []float64{0}
+	}
+	return []float64{q / a, c / q}
+}
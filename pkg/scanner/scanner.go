@@ -13,25 +13,45 @@ import (
 	"github.com/omniskop/firefly/pkg/project"
 )
 
-// Frame contains the time of the frame and the pixel colors
+// Frame contains the time of the frame and the pixel colors.
+// All pixels are opaque colors with 16 bits per channel.
 type Frame struct {
 	Time   float64
-	Pixels []color.Color
+	Pixels []color.RGBA64
 }
 
 // A Scanner can be used to scan lines of a project into separate pixel colors
 type Scanner struct {
-	scene   *project.Scene
-	mapping *Mapping
-	mutex   *sync.Mutex
+	scene      *project.Scene
+	mapping    *Mapping
+	motionBlur *motionBlur
+	buffers    *scanBuffers
+	mutex      *sync.Mutex
+}
+
+// motionBlur contains the settings for the temporal supersampling of a scanner
+type motionBlur struct {
+	samples  int     // number of samples that are taken for each frame
+	interval float64 // the duration in seconds that the samples are spread across
+}
+
+// scanBuffers contains memory that is reused between scans to prevent allocations
+type scanBuffers struct {
+	elements  []project.IndexedElement
+	fragments [][]span
+	edges     []edge
+	pixels    []blendedColor
+	sums      []blendedColor
 }
 
 // New creates a new scanner on the project. Size should be the number of led's.
 func New(scene *project.Scene, size int) Scanner {
 	return Scanner{
-		scene:   scene,
-		mapping: NewLinearMapping(size),
-		mutex:   new(sync.Mutex),
+		scene:      scene,
+		mapping:    NewLinearMapping(size),
+		motionBlur: &motionBlur{samples: 1},
+		buffers:    new(scanBuffers),
+		mutex:      new(sync.Mutex),
 	}
 }
 
@@ -48,123 +68,248 @@ func (s *Scanner) SetMapping(m Mapping) {
 	s.mutex.Unlock()
 }
 
+// SetMotionBlur lets the scanner take multiple samples for each frame that are spread evenly across the interval
+// (in seconds) around the time of the frame. The resulting colors are averaged which causes fast moving elements
+// to be blurred along their path instead of jumping from pixel to pixel.
+// Usually the interval should be the time between two frames. A sample count of 1 or less disables motion blur.
+func (s *Scanner) SetMotionBlur(samples int, interval float64) {
+	if samples < 1 {
+		samples = 1
+	}
+	s.mutex.Lock()
+	s.motionBlur = &motionBlur{samples: samples, interval: math.Max(0, interval)}
+	s.mutex.Unlock()
+}
+
 // Scans a line at the specified time and the returns the frame
 func (s Scanner) Scan(time float64) Frame {
+	var frame Frame
+	s.ScanInto(time, &frame)
+	return frame
+}
+
+// ScanInto scans a line at the specified time into the frame.
+// The pixels of the frame are reused if they have enough capacity which allows scanning without allocations.
+func (s Scanner) ScanInto(time float64, frame *Frame) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	size := s.mapping.Pixels()
-	var frame = Frame{
-		Time:   time,
-		Pixels: make([]color.Color, size),
+	frame.Time = time
+	if cap(frame.Pixels) >= size {
+		frame.Pixels = frame.Pixels[:size]
+	} else {
+		frame.Pixels = make([]color.RGBA64, size)
 	}
 
 	// set offsets to black
+	black := color.RGBA64{A: 0xffff}
 	for i := 0; i < s.mapping.StartOffset; i++ {
-		frame.Pixels[i] = color.Black
+		frame.Pixels[i] = black
 	}
 	for i := size - s.mapping.EndOffset; i < size; i++ {
-		frame.Pixels[i] = color.Black
+		frame.Pixels[i] = black
+	}
+
+	samples := s.motionBlur.samples
+	if samples <= 1 {
+		pixels := s.scanSample(time)
+		for pixelIndex := s.mapping.StartOffset; pixelIndex < size-s.mapping.EndOffset; pixelIndex++ {
+			frame.Pixels[pixelIndex] = pixels[pixelIndex].onBlack()
+		}
+		return
+	}
+
+	// The samples are centered on the time of the frame. The light that every sample would emit is averaged.
+	sums := resizeBlendedColors(s.buffers.sums, size)
+	s.buffers.sums = sums
+	for sample := 0; sample < samples; sample++ {
+		sampleTime := time + s.motionBlur.interval*((float64(sample)+0.5)/float64(samples)-0.5)
+		for pixelIndex, pixel := range s.scanSample(sampleTime) {
+			sums[pixelIndex].r += pixel.r * pixel.a
+			sums[pixelIndex].g += pixel.g * pixel.a
+			sums[pixelIndex].b += pixel.b * pixel.a
+		}
+	}
+	for pixelIndex := s.mapping.StartOffset; pixelIndex < size-s.mapping.EndOffset; pixelIndex++ {
+		average := blendedColor{
+			r: sums[pixelIndex].r / float64(samples),
+			g: sums[pixelIndex].g / float64(samples),
+			b: sums[pixelIndex].b / float64(samples),
+			a: 1,
+		}
+		frame.Pixels[pixelIndex] = average.onBlack()
 	}
+}
+
+// scanSample composites all elements at the specified time and returns the resulting color for every pixel.
+// Pixels that are part of the offsets of the mapping stay transparent.
+// The returned slice is only valid until the next call.
+// The mutex of the scanner needs to be locked while calling this.
+func (s Scanner) scanSample(time float64) []blendedColor {
+	size := s.mapping.Pixels()
+	pixels := resizeBlendedColors(s.buffers.pixels, size) // all pixels start out fully transparent
+	s.buffers.pixels = pixels
 
-	elements := s.scene.GetElementsAt(time)
+	elements := s.scene.AppendElementsAt(s.buffers.elements[:0], time)
+	s.buffers.elements = elements
 	// logrus.WithField("elements", len(elements)).Debug("  ====== New Scan ======  ", time)
 
 	// sort the elements in the correct ZIndex order
-	sort.Slice(elements, func(i, j int) bool {
-		return elements[i].ZIndex < elements[j].ZIndex
-	})
+	sort.Stable(byZIndex(elements))
 
 	// Create a list of fragments. One for each element.
-	// Theoretically an element could have more than one fragment but we currently ignore this case.
-	// A fragment contains a start and a stop position.
-	// Start is the first pixel where the elements starts to be visible
-	// Stop is the last pixel where the elements is visible
-	fragments := make([]struct {
-		start float64
-		stop  float64
-	}, len(elements))
-
+	// A fragment contains all spans where the element is visible at this time.
+	// Elements with more complex shapes can cover multiple separate spans.
+	for len(s.buffers.fragments) < len(elements) {
+		s.buffers.fragments = append(s.buffers.fragments, nil)
+	}
+	fragments := s.buffers.fragments[:len(elements)]
 	for i, element := range elements {
-		a, b := getPixelCoverageOfPath(element.Shape.Path(), time)
-		fragments[i].start = a
-		fragments[i].stop = b
+		fragments[i] = s.buffers.appendSpansOfPath(fragments[i][:0], element.Path, time)
 	}
 
 	// iterate through all pixels ...
 	for pixelIndex := s.mapping.StartOffset; pixelIndex < size-s.mapping.EndOffset; pixelIndex++ {
 		pixelPosition, pixelWidth := s.mapping.GetPixelPosition(pixelIndex)
-		pixelPosition += pixelWidth / 2                             // use the center of the pixel for better results
-		pixelInScene := vectorpath.Point{P: pixelPosition, T: time} // the location of the pixel in the scene
-		var pixelColor color.Color = color.Black
+		var pixelColor blendedColor
 
 		// ... and through all fragments ...
 		for fragmentIndex, fragment := range fragments {
-			// ... to check which fragments are visible in each pixel
-			if fragment.start <= pixelPosition && fragment.stop >= pixelPosition {
-				pixelColor = addColors(pixelColor, getFill(elements[fragmentIndex], pixelInScene))
+			// ... to check how much of each pixel is covered by the fragments
+			coverage, center := getFragmentCoverage(fragment, pixelPosition, pixelWidth)
+			if coverage <= 0 {
+				continue
 			}
+			// the fill is sampled in the center of the covered part of the pixel
+			pixelInScene := vectorpath.Point{P: center, T: time} // the location of the sample in the scene
+			element := elements[fragmentIndex]
+			pixelColor = pixelColor.blend(newBlendedColor(getFill(element, pixelInScene)), coverage, element.BlendMode)
 		}
-		frame.Pixels[pixelIndex] = pixelColor
+		pixels[pixelIndex] = pixelColor
 	}
 
-	return frame
+	return pixels
+}
+
+// resizeBlendedColors returns a slice of transparent colors with the size that reuses the memory of colors if possible
+func resizeBlendedColors(colors []blendedColor, size int) []blendedColor {
+	if cap(colors) < size {
+		return make([]blendedColor, size)
+	}
+	colors = colors[:size]
+	for i := range colors {
+		colors[i] = blendedColor{}
+	}
+	return colors
 }
 
-// getPixelCoverageOfPath returns the start and end positions [0, 1] where the shape is visible at the specific time
-func getPixelCoverageOfPath(path vectorpath.Path, time float64) (float64, float64) {
+// byZIndex sorts elements by their ZIndex
+type byZIndex []project.IndexedElement
+
+func (e byZIndex) Len() int           { return len(e) }
+func (e byZIndex) Less(i, j int) bool { return e[i].ZIndex < e[j].ZIndex }
+func (e byZIndex) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// A span is a range on the position axis that is covered by an element
+type span struct {
+	start float64
+	stop  float64
+}
+
+// getFragmentCoverage returns the fraction [0, 1] of the pixel that is covered by the spans of a fragment
+// as well as the center of the covered part of the pixel.
+// Pixels without a width are treated as a single point that is either fully covered or not at all.
+func getFragmentCoverage(spans []span, pixelPosition, pixelWidth float64) (float64, float64) {
+	if pixelWidth <= 0 {
+		for _, s := range spans {
+			if s.start <= pixelPosition && s.stop >= pixelPosition {
+				return 1, pixelPosition
+			}
+		}
+		return 0, pixelPosition
+	}
+
+	var covered, weightedCenter float64
+	for _, s := range spans {
+		coveredStart := math.Max(s.start, pixelPosition)
+		coveredStop := math.Min(s.stop, pixelPosition+pixelWidth)
+		if coveredStop <= coveredStart {
+			continue
+		}
+		covered += coveredStop - coveredStart
+		weightedCenter += (coveredStop - coveredStart) * (coveredStart + coveredStop) / 2
+	}
+	if covered <= 0 {
+		return 0, pixelPosition + pixelWidth/2
+	}
+	return math.Min(1, covered/pixelWidth), weightedCenter / covered
+}
+
+// appendSpansOfPath appends all spans on the position axis [0, 1] where the path is filled at the specific time
+// to spans. The spans are sorted and don't overlap.
+func (b *scanBuffers) appendSpansOfPath(spans []span, path vectorpath.Path, time float64) []span {
 	currentPoint := path.Start
-	var edges []vectorpath.Point
+	edges := b.edges[:0]
 	for _, segment := range path.Segments {
-		newPoint := currentPoint.Add(segment.EndPoint())
-		if (currentPoint.T < time && newPoint.T > time) || (currentPoint.T > time && newPoint.T < time) {
-			edges = append(edges, vectorpath.Point{P: currentPoint.P + getSegmentEdge(segment, time-currentPoint.T), T: time})
+		segmentStart := len(edges)
+		edges = appendSegmentEdges(edges, segment, time-currentPoint.T)
+		for i := segmentStart; i < len(edges); i++ {
+			edges[i].position += currentPoint.P
 		}
-		currentPoint = newPoint
-	}
-
-	if len(edges) >= 2 {
-		return math.Min(edges[0].P, edges[1].P), math.Max(edges[0].P, edges[1].P)
-	}
-	logrus.WithField("edges", len(edges)).Warn("GetPixelCoverage: not enough edges found")
-	return 0, 0
-}
-
-// getSegmentEdge returns the position where the segment surpasses the point in time.
-// time and the returned position are relative to the start of the segment
-func getSegmentEdge(segment vectorpath.Segment, time float64) float64 {
-	switch obj := segment.(type) {
-	case *vectorpath.Line:
-		return obj.P * (time / obj.T)
-	case *vectorpath.QuadCurve:
-		var foundProgress = math.NaN()
-		if !floatsEqual(obj.Control.T*2, obj.End.T) {
-			p1 := (obj.Control.T - math.Sqrt(math.Pow(obj.Control.T, 2)-2*obj.Control.T*time+obj.End.T*time)) / (2*obj.Control.T - obj.End.T)
-			p2 := (obj.Control.T + math.Sqrt(math.Pow(obj.Control.T, 2)-2*obj.Control.T*time+obj.End.T*time)) / (2*obj.Control.T - obj.End.T)
-			// theoretically both could be correct but we currently only support one hit
-			if p1 >= 0 && p1 <= 1 {
-				foundProgress = p1
-			}
-			if p2 >= 0 && p2 <= 1 {
-				foundProgress = p2
+		currentPoint = currentPoint.Add(segment.EndPoint())
+	}
+	b.edges = edges
+	if len(edges)%2 != 0 {
+		// a closed path always crosses a point in time an even number of times
+		logrus.WithField("edges", len(edges)).Warn("GetSpansOfPath: path is not closed")
+	}
+
+	sortEdges(edges)
+
+	// walk along the position axis and keep track of whether we are inside the path or not
+	firstSpan := len(spans)
+	var start float64
+	winding := 0
+	for _, e := range edges {
+		wasInside := isInside(winding, path.FillRule)
+		winding += e.direction
+		isInsideNow := isInside(winding, path.FillRule)
+		if !wasInside && isInsideNow {
+			start = e.position
+		} else if wasInside && !isInsideNow {
+			if len(spans) > firstSpan && spans[len(spans)-1].stop >= start {
+				// two spans that touch each other are merged
+				spans[len(spans)-1].stop = e.position
+			} else {
+				spans = append(spans, span{start: start, stop: e.position})
 			}
-		} else {
-			foundProgress = time / obj.End.T
 		}
+	}
+	return spans
+}
 
-		if math.IsNaN(foundProgress) {
-			return 0
+// sortEdges sorts the edges by their position.
+// Paths only have a few edges at a time, so a simple insertion sort is sufficient and doesn't allocate memory.
+func sortEdges(edges []edge) {
+	for i := 1; i < len(edges); i++ {
+		for j := i; j > 0 && edges[j].position < edges[j-1].position; j-- {
+			edges[j], edges[j-1] = edges[j-1], edges[j]
 		}
-		return 2*foundProgress*(1-foundProgress)*obj.Control.P + math.Pow(foundProgress, 2)*obj.End.P
-	case *vectorpath.CubicCurve:
-		return 0
 	}
-	return 0
+}
+
+// isInside returns true if an area with the winding number is inside of a path with the fill rule
+func isInside(winding int, rule vectorpath.FillRule) bool {
+	if rule == vectorpath.NonZeroFill {
+		return winding != 0
+	}
+	return winding%2 != 0
 }
 
 // getFill takes an element and a point inside it to return the correct color according to the pattern of the element.
-func getFill(element *project.Element, point vectorpath.Point) color.Color {
-	bounds := element.Shape.Bounds()
+func getFill(element project.IndexedElement, point vectorpath.Point) color.Color {
+	bounds := element.Bounds
 	point = point.Sub(bounds.Location)
 	point = vectorpath.Point{
 		P: point.P / bounds.Dimensions.P,
@@ -173,33 +318,13 @@ func getFill(element *project.Element, point vectorpath.Point) color.Color {
 	switch pattern := element.Pattern.(type) {
 	case *project.SolidColor:
 		return pattern.Color
-	case *project.LinearGradient:
-		toPoint := point.Sub(pattern.Start.Point)                // vector from the start of the gradient to the point of interest
-		gradTrack := pattern.Stop.Point.Sub(pattern.Start.Point) // vector from start to end of the gradient
-
-		// calculate the progress through projection
-		progress := dotProduct(toPoint, gradTrack) / math.Pow(length(gradTrack), 2)
-
-		return interpolateColors(pattern.Start.Color, pattern.Stop.Color, progress)
+	case project.Gradient:
+		return pattern.ColorAt(pattern.Progress(point))
 	default:
 		return nil
 	}
 }
 
-// interpolateColors interpolates linearly between colorA and colorB bases on progress.
-// progress gets clamped between 0 and 1
-func interpolateColors(colorA color.Color, colorB color.Color, progress float64) color.Color {
-	progress = math.Min(1, math.Max(0, progress))
-	aR, aG, aB, aA := colorToFloats(colorA)
-	bR, bG, bB, bA := colorToFloats(colorB)
-	return floatsToColor(
-		aR+(bR-aR)*progress,
-		aG+(bG-aG)*progress,
-		aB+(bB-aB)*progress,
-		aA+(bA-aA)*progress,
-	)
-}
-
 // colorToFloats returns the r, g, b and a values of the color as floating point numbers between [0, 65536[
 func colorToFloats(c color.Color) (float64, float64, float64, float64) {
 	r, g, b, a := c.RGBA()
@@ -207,7 +332,7 @@ func colorToFloats(c color.Color) (float64, float64, float64, float64) {
 }
 
 // floatsToColors returns a new colors based on the rgba components as floats between [0, 65536[
-func floatsToColor(r float64, g float64, b float64, a float64) color.Color {
+func floatsToColor(r float64, g float64, b float64, a float64) color.RGBA64 {
 	return color.RGBA64{
 		R: uint16(math.Round(r)),
 		G: uint16(math.Round(g)),
@@ -216,29 +341,59 @@ func floatsToColor(r float64, g float64, b float64, a float64) color.Color {
 	}
 }
 
-// addColors combines the colors one and two in a way that two overlays one.
-// The alpha value of two will be used to blend the color.
-// If two has an alpha value of 255 it will completely override one and if the alpha value is 0 one will be fully visible.
-// The transparency of one will be ignored and the resulting color is completely opaque.
-func addColors(one color.Color, two color.Color) color.Color {
-	oneR, oneG, oneB, _ := colorToFloats(one)
-	twoR, twoG, twoB, twoA := colorToFloats(two)
-	progress := twoA / 65535 // 65535 = 2^16-1 is the highest possible value for twoA
-	blendR := oneR*(1-progress) + twoR*progress
-	blendG := oneG*(1-progress) + twoG*progress
-	blendB := oneB*(1-progress) + twoB*progress
+// blendedColor is a color with straight alpha whose components are in the range of [0, 1]
+type blendedColor struct {
+	r, g, b, a float64
+}
 
-	return floatsToColor(blendR, blendG, blendB, 65535)
+func newBlendedColor(c color.Color) blendedColor {
+	r, g, b, a := colorToFloats(c)
+	return blendedColor{r: r / 65535, g: g / 65535, b: b / 65535, a: a / 65535}
 }
 
-func dotProduct(a, b vectorpath.Point) float64 {
-	return a.P*b.P + a.T*b.T
+// blend composites the color top with the given coverage on top of this color by using the blend mode.
+// The alpha of both colors is taken into account. Where this color is transparent top will be drawn as it is,
+// where it is opaque the result of the blend mode will be used.
+// See https://www.w3.org/TR/compositing-1/#blending
+func (c blendedColor) blend(top blendedColor, coverage float64, mode project.BlendMode) blendedColor {
+	topAlpha := top.a * coverage
+	alpha := topAlpha + c.a*(1-topAlpha)
+	if alpha <= 0 {
+		return blendedColor{}
+	}
+	channel := func(bottom, top float64) float64 {
+		blended := (1-c.a)*top + c.a*blendChannel(bottom, top, mode)
+		return (topAlpha*blended + c.a*bottom*(1-topAlpha)) / alpha
+	}
+	return blendedColor{
+		r: channel(c.r, top.r),
+		g: channel(c.g, top.g),
+		b: channel(c.b, top.b),
+		a: alpha,
+	}
 }
 
-func length(a vectorpath.Point) float64 {
-	return math.Sqrt(a.P*a.P + a.T*a.T)
+// onBlack returns the opaque color that results from drawing this color on top of black
+func (c blendedColor) onBlack() color.RGBA64 {
+	return floatsToColor(c.r*c.a*65535, c.g*c.a*65535, c.b*c.a*65535, 65535)
 }
 
-func floatsEqual(a, b float64) bool {
-	return math.Abs(a-b) < 0.00000001
+// blendChannel combines the color channels bottom and top in the range of [0, 1] according to the blend mode
+func blendChannel(bottom, top float64, mode project.BlendMode) float64 {
+	switch mode {
+	case project.BlendAdd:
+		return math.Min(1, bottom+top)
+	case project.BlendMultiply:
+		return bottom * top
+	case project.BlendScreen:
+		return bottom + top - bottom*top
+	case project.BlendLighten:
+		return math.Max(bottom, top)
+	case project.BlendDarken:
+		return math.Min(bottom, top)
+	case project.BlendSubtract:
+		return math.Max(0, bottom-top)
+	default:
+		return top
+	}
 }
@@ -0,0 +1,64 @@
+package show
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/scanner"
+	"github.com/omniskop/firefly/pkg/streamer"
+)
+
+// defaultGamma is applied to channels whose calibration doesn't specify a gamma, because controllers send the
+// frames to the LEDs as they are.
+const defaultGamma = 2.2
+
+// Export renders the project at the frame rate and writes the frames as a show to the destination.
+// The mapping, calibration and power limit of the output are applied to every frame like they would be while
+// streaming to the output.
+// If progress is not nil it is called after every frame with the fraction of the frames that have been written.
+// When it returns false the export stops with ErrCanceled and the destination contains an incomplete show.
+func Export(dst io.WriteSeeker, proj *project.Project, output streamer.OutputConfig, frameRate int, progress func(done float64) bool) (Header, error) {
+	calibrator, err := output.Calibration.NewCalibrator(defaultGamma)
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid calibration: %w", err)
+	}
+	order := output.Calibration.ChannelOrder
+	if order == "" {
+		order = "RGB"
+	}
+	sca := scanner.New(&proj.Scene, 1)
+	sca.SetMapping(output.Mapping)
+	writer, err := NewWriter(dst, Header{
+		FrameRate:    frameRate,
+		Pixels:       output.Mapping.Pixels(),
+		Channels:     calibrator.Channels(),
+		ChannelOrder: order,
+		Duration:     time.Duration(proj.Duration * float64(time.Second)),
+	})
+	if err != nil {
+		return Header{}, err
+	}
+
+	limiter := streamer.NewPowerLimiter(output.Power.Calibrated(output.Calibration, defaultGamma))
+	frames := int(math.Ceil(proj.Duration * float64(frameRate)))
+	pixels := make([]byte, output.Mapping.Pixels()*calibrator.Channels())
+	var frame scanner.Frame
+	for i := 0; i < frames; i++ {
+		sca.ScanInto(float64(i)/float64(frameRate), &frame)
+		data := pixels
+		for _, pixel := range limiter.Limit(frame).Pixels {
+			data = data[calibrator.Write(data, pixel):]
+		}
+		err = writer.WriteFrame(pixels)
+		if err != nil {
+			return Header{}, err
+		}
+		if progress != nil && !progress(float64(i+1)/float64(frames)) {
+			return Header{}, ErrCanceled
+		}
+	}
+	return writer.Close()
+}
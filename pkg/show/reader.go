@@ -0,0 +1,173 @@
+package show
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Reader reads the frames of a show
+type Reader struct {
+	header   Header
+	data     io.Reader // the frames of the show, everything read from it is added to the checksum
+	checksum hash.Hash32
+	pixels   []byte // the pixels of the current frame
+	encoded  []byte // reused between frames
+	read     int    // the number of frames that have been read
+}
+
+// NewReader reads the header of the show and returns ErrInvalidShow if the source doesn't contain a show
+func NewReader(src io.Reader) (*Reader, error) {
+	reader := bufio.NewReader(src)
+	data := make([]byte, headerSize)
+	_, err := io.ReadFull(reader, data)
+	if err != nil {
+		return nil, ErrInvalidShow
+	}
+	if [4]byte{data[0], data[1], data[2], data[3]} != magic || data[4] != version {
+		return nil, ErrInvalidShow
+	}
+	header := Header{
+		Channels:     int(data[5]),
+		ChannelOrder: string(trimZeros(data[6:10])),
+		FrameRate:    int(binary.LittleEndian.Uint16(data[10:])),
+		Pixels:       int(binary.LittleEndian.Uint32(data[12:])),
+		Frames:       int(binary.LittleEndian.Uint32(data[16:])),
+		Duration:     time.Duration(binary.LittleEndian.Uint32(data[20:])) * time.Millisecond,
+		Checksum:     binary.LittleEndian.Uint32(data[24:]),
+	}
+	if header.Channels == 0 || header.FrameRate == 0 || !header.validFrameSize() {
+		return nil, ErrInvalidShow
+	}
+
+	r := &Reader{
+		header:   header,
+		checksum: crc32.NewIEEE(),
+		pixels:   make([]byte, header.frameSize()),
+	}
+	r.data = io.TeeReader(reader, r.checksum)
+	return r, nil
+}
+
+func trimZeros(data []byte) []byte {
+	for i, b := range data {
+		if b == 0 {
+			return data[:i]
+		}
+	}
+	return data
+}
+
+// Header returns the header of the show
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the calibrated channels of the pixels of the next frame. After the last frame io.EOF is returned,
+// or ErrChecksum if the frames don't match the checksum. The returned slice is only valid until the next call.
+func (r *Reader) Next() ([]byte, error) {
+	if r.read == r.header.Frames {
+		if r.checksum.Sum32() != r.header.Checksum {
+			return nil, ErrChecksum
+		}
+		return nil, io.EOF
+	}
+
+	var frameHeader [5]byte
+	if _, err := io.ReadFull(r.data, frameHeader[:]); err != nil {
+		return nil, truncated(err)
+	}
+	length := binary.LittleEndian.Uint32(frameHeader[1:])
+	// no encoding needs more space than raw pixels with a run header in front of every pixel
+	if uint64(length) > uint64(r.header.Pixels)*uint64(r.header.Channels+4) {
+		return nil, fmt.Errorf("show: frame %d is too long (%d bytes)", r.read, length)
+	}
+	if cap(r.encoded) < int(length) {
+		r.encoded = make([]byte, length)
+	}
+	r.encoded = r.encoded[:length]
+	if _, err := io.ReadFull(r.data, r.encoded); err != nil {
+		return nil, truncated(err)
+	}
+
+	var err error
+	switch frameHeader[0] {
+	case encodingRaw:
+		if len(r.encoded) != len(r.pixels) {
+			err = fmt.Errorf("raw frame has %d bytes instead of %d", len(r.encoded), len(r.pixels))
+		}
+		copy(r.pixels, r.encoded)
+	case encodingRLE:
+		err = r.decodeRLE()
+	case encodingDelta:
+		if r.read == 0 {
+			err = fmt.Errorf("the first frame can't be a delta")
+		} else {
+			err = r.decodeDelta()
+		}
+	default:
+		err = fmt.Errorf("unknown encoding %d", frameHeader[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("show: frame %d: %w", r.read, err)
+	}
+	r.read++
+	return r.pixels, nil
+}
+
+func (r *Reader) decodeRLE() error {
+	channels := r.header.Channels
+	data := r.encoded
+	position := 0
+	for len(data) > 0 {
+		if len(data) < 2+channels {
+			return fmt.Errorf("incomplete run")
+		}
+		count := int(binary.LittleEndian.Uint16(data))
+		pixel := data[2 : 2+channels]
+		data = data[2+channels:]
+		if position+count*channels > len(r.pixels) {
+			return fmt.Errorf("runs exceed %d pixels", r.header.Pixels)
+		}
+		for i := 0; i < count; i++ {
+			position += copy(r.pixels[position:], pixel)
+		}
+	}
+	if position != len(r.pixels) {
+		return fmt.Errorf("runs cover %d of %d pixels", position/channels, r.header.Pixels)
+	}
+	return nil
+}
+
+func (r *Reader) decodeDelta() error {
+	channels := r.header.Channels
+	data := r.encoded
+	position := 0
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return fmt.Errorf("incomplete run")
+		}
+		unchanged := int(binary.LittleEndian.Uint16(data))
+		count := int(binary.LittleEndian.Uint16(data[2:]))
+		data = data[4:]
+		position += unchanged * channels
+		if position+count*channels > len(r.pixels) || count*channels > len(data) {
+			return fmt.Errorf("invalid run of %d pixels", count)
+		}
+		position += copy(r.pixels[position:], data[:count*channels])
+		data = data[count*channels:]
+	}
+	return nil
+}
+
+// truncated converts an EOF in the middle of the show into an error
+func truncated(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
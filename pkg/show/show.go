@@ -0,0 +1,77 @@
+// Package show contains a compact file format for shows that have been rendered in advance.
+// Standalone controllers can play a show by sending the frames to their LEDs without scanning the project.
+//
+// All numbers are stored in little endian. The file starts with a header of 32 bytes:
+//
+//	0   [4]byte  magic "FFSH"
+//	4   uint8    version
+//	5   uint8    the number of channels of a pixel
+//	6   [4]byte  the channel order like "GRB" padded with zeros
+//	10  uint16   frames per second
+//	12  uint32   the number of pixels
+//	16  uint32   the number of frames
+//	20  uint32   the duration of the project in milliseconds
+//	24  uint32   CRC-32 (IEEE) of all bytes after the header
+//	28  uint32   reserved
+//
+// Every frame starts with the encoding as an uint8 followed by the length of the data as an uint32.
+// The data contains the calibrated channels of the pixels in one of these encodings:
+//
+//	raw    the channels of all pixels
+//	rle    runs of identical pixels, each run is an uint16 count followed by the channels of the pixel
+//	delta  an uint16 number of unchanged pixels followed by an uint16 count and the channels of count pixels,
+//	       repeated as often as needed. Pixels after the last run didn't change since the previous frame.
+package show
+
+import (
+	"errors"
+	"time"
+)
+
+var magic = [4]byte{'F', 'F', 'S', 'H'}
+
+const (
+	version    = 1
+	headerSize = 32
+	// maxRun is the largest count of a run in the rle and delta encoding
+	maxRun = 0xffff
+	// maxFrameSize is the largest number of bytes of the pixels of a frame. It prevents a corrupt header from
+	// allocating an arbitrary amount of memory.
+	maxFrameSize = 1 << 24
+)
+
+const (
+	encodingRaw = iota
+	encodingRLE
+	encodingDelta
+)
+
+var (
+	// ErrInvalidShow is returned when a file is not a show of a supported version
+	ErrInvalidShow = errors.New("not a firefly show")
+	// ErrChecksum is returned after the last frame if the frames don't match the checksum of the header
+	ErrChecksum = errors.New("show: checksum mismatch")
+	// ErrCanceled is returned by Export if the export has been canceled
+	ErrCanceled = errors.New("show: export has been canceled")
+)
+
+// Header describes the frames of a show
+type Header struct {
+	FrameRate    int    // frames per second
+	Pixels       int    // the number of pixels of every frame
+	Channels     int    // the number of bytes per pixel
+	ChannelOrder string // the order of the channels, only for information
+	Frames       int    // the number of frames, set by the Writer
+	Duration     time.Duration
+	Checksum     uint32 // set by the Writer
+}
+
+// frameSize returns the number of bytes of the pixels of a frame
+func (h Header) frameSize() int {
+	return h.Pixels * h.Channels
+}
+
+// validFrameSize returns true if the frames of the header don't exceed the maxFrameSize
+func (h Header) validFrameSize() bool {
+	return h.Pixels >= 0 && h.Channels >= 0 && uint64(h.Pixels)*uint64(h.Channels) <= maxFrameSize
+}
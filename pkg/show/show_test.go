@@ -0,0 +1,197 @@
+package show
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// seekBuffer is an in-memory io.WriteSeeker for the Writer
+type seekBuffer struct {
+	data     []byte
+	position int
+}
+
+func (b *seekBuffer) Write(p []byte) (int, error) {
+	if end := b.position + len(p); end > len(b.data) {
+		b.data = append(b.data, make([]byte, end-len(b.data))...)
+	}
+	b.position += copy(b.data[b.position:], p)
+	return len(p), nil
+}
+
+func (b *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(b.position)
+	case io.SeekEnd:
+		offset += int64(len(b.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	b.position = int(offset)
+	return offset, nil
+}
+
+// writeShow writes the frames as a show and returns the encoded file
+func writeShow(t *testing.T, header Header, frames [][]byte) []byte {
+	t.Helper()
+	var buffer seekBuffer
+	writer, err := NewWriter(&buffer, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, frame := range frames {
+		if err = writer.WriteFrame(frame); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+	if _, err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.data
+}
+
+// readShow reads all frames of the show and returns the error that ended the show
+func readShow(t *testing.T, data []byte) (Header, [][]byte, error) {
+	t.Helper()
+	reader, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames [][]byte
+	for {
+		frame, err := reader.Next()
+		if err != nil {
+			return reader.Header(), frames, err
+		}
+		frames = append(frames, append([]byte(nil), frame...))
+	}
+}
+
+// frameEncodings returns the encoding of every frame of the show
+func frameEncodings(data []byte) []byte {
+	var encodings []byte
+	for data = data[headerSize:]; len(data) >= 5; {
+		encodings = append(encodings, data[0])
+		data = data[5+binary.LittleEndian.Uint32(data[1:]):]
+	}
+	return encodings
+}
+
+func TestRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	header := Header{FrameRate: 30, Pixels: 50, Channels: 4, ChannelOrder: "GRBW", Duration: 1234567 * time.Microsecond}
+	var frames [][]byte
+	for i := 0; i < 20; i++ {
+		frame := make([]byte, header.frameSize())
+		if i > 0 {
+			copy(frame, frames[i-1])
+		}
+		// change a few pixels so that different encodings are used
+		for j := random.Intn(header.Pixels); j > 0; j-- {
+			frame[random.Intn(len(frame))] = byte(random.Intn(0x100))
+		}
+		frames = append(frames, frame)
+	}
+
+	read, readFrames, err := readShow(t, writeShow(t, header, frames))
+	if err != io.EOF {
+		t.Fatalf("the show ended with %v instead of io.EOF", err)
+	}
+	if read.FrameRate != header.FrameRate || read.Pixels != header.Pixels || read.Channels != header.Channels ||
+		read.ChannelOrder != header.ChannelOrder || read.Frames != len(frames) || read.Duration != 1234*time.Millisecond {
+		t.Errorf("read header %+v doesn't match %+v", read, header)
+	}
+	if len(readFrames) != len(frames) {
+		t.Fatalf("read %d of %d frames", len(readFrames), len(frames))
+	}
+	for i := range frames {
+		if !bytes.Equal(readFrames[i], frames[i]) {
+			t.Errorf("frame %d is %x instead of %x", i, readFrames[i], frames[i])
+		}
+	}
+}
+
+func TestEncodingSelection(t *testing.T) {
+	const channels = 3
+	pixels := maxRun + 10
+	header := Header{FrameRate: 25, Pixels: pixels, Channels: channels}
+
+	noise := make([]byte, pixels*channels)
+	rand.New(rand.NewSource(2)).Read(noise)
+	// more identical pixels than fit into a single run
+	uniform := bytes.Repeat([]byte{1, 2, 3}, pixels)
+	// a single changed pixel after more unchanged pixels than fit into a single run
+	lateChange := append([]byte(nil), uniform...)
+	lateChange[(maxRun+5)*channels] = 9
+	frames := [][]byte{noise, uniform, lateChange}
+
+	data := writeShow(t, header, frames)
+	encodings := frameEncodings(data)
+	expected := []byte{encodingRaw, encodingRLE, encodingDelta}
+	if !bytes.Equal(encodings, expected) {
+		t.Errorf("the frames use the encodings %v instead of %v", encodings, expected)
+	}
+
+	_, readFrames, err := readShow(t, data)
+	if err != io.EOF {
+		t.Fatalf("the show ended with %v instead of io.EOF", err)
+	}
+	for i := range frames {
+		if i >= len(readFrames) || !bytes.Equal(readFrames[i], frames[i]) {
+			t.Errorf("frame %d doesn't match after decoding", i)
+		}
+	}
+
+	rle := encodeRLE(nil, uniform, channels)
+	expectedRLE := []byte{0xff, 0xff, 1, 2, 3, 10, 0, 1, 2, 3}
+	if !bytes.Equal(rle, expectedRLE) {
+		t.Errorf("run length encoding is %x instead of %x", rle, expectedRLE)
+	}
+	delta := encodeDelta(nil, uniform, lateChange, channels)
+	expectedDelta := []byte{0xff, 0xff, 0, 0, 5, 0, 1, 0, 9, 2, 3}
+	if !bytes.Equal(delta, expectedDelta) {
+		t.Errorf("delta encoding is %x instead of %x", delta, expectedDelta)
+	}
+	changed := encodeDelta(nil, uniform, noise, channels)
+	if runs := binary.LittleEndian.Uint16(changed[2:]); runs != maxRun {
+		t.Errorf("the first run of changed pixels contains %d instead of %d pixels", runs, maxRun)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	header := Header{FrameRate: 10, Pixels: 4, Channels: 3}
+	frames := [][]byte{
+		{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+		{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 13},
+	}
+	data := writeShow(t, header, frames)
+	// the last byte is a pixel of the second frame, so the frame can still be decoded
+	data[len(data)-1] ^= 0xff
+
+	_, readFrames, err := readShow(t, data)
+	if len(readFrames) != len(frames) {
+		t.Fatalf("read %d of %d frames before the error %v", len(readFrames), len(frames), err)
+	}
+	if err != ErrChecksum {
+		t.Errorf("the show ended with %v instead of ErrChecksum", err)
+	}
+}
+
+func TestFrameSizeLimit(t *testing.T) {
+	header := Header{FrameRate: 30, Pixels: maxFrameSize / 4, Channels: 5}
+	_, err := NewReader(bytes.NewReader(encodeHeader(header)))
+	if err != ErrInvalidShow {
+		t.Errorf("a header with %d bytes per frame returned %v instead of ErrInvalidShow", header.frameSize(), err)
+	}
+	if _, err = NewWriter(&seekBuffer{}, header); err == nil {
+		t.Errorf("the writer accepted a header with %d bytes per frame", header.frameSize())
+	}
+}
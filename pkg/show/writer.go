@@ -0,0 +1,182 @@
+package show
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Writer writes the frames of a show. Every frame is stored in the encoding that needs the least space.
+type Writer struct {
+	dst      io.WriteSeeker
+	writer   *bufio.Writer
+	checksum hash.Hash32
+	header   Header
+	previous []byte // the pixels of the previous frame
+	rle      []byte // reused between frames
+	delta    []byte // reused between frames
+	err      error  // the first error that occurred
+}
+
+// NewWriter creates a writer that writes the frames to the destination.
+// The header is written again when the writer is closed, so the destination needs to support seeking.
+func NewWriter(dst io.WriteSeeker, header Header) (*Writer, error) {
+	if header.FrameRate < 1 || header.FrameRate > 0xffff {
+		return nil, fmt.Errorf("show: invalid frame rate %d", header.FrameRate)
+	}
+	if header.Channels < 1 || header.Channels > 0xff {
+		return nil, fmt.Errorf("show: invalid number of channels %d", header.Channels)
+	}
+	if header.Pixels < 0 || uint64(header.Pixels) > 0xffffffff {
+		return nil, fmt.Errorf("show: invalid number of pixels %d", header.Pixels)
+	}
+	if !header.validFrameSize() {
+		return nil, fmt.Errorf("show: a frame of %d pixels exceeds %d bytes", header.Pixels, maxFrameSize)
+	}
+	if len(header.ChannelOrder) > 4 {
+		return nil, fmt.Errorf("show: channel order %q is too long", header.ChannelOrder)
+	}
+	header.Frames = 0
+	w := &Writer{
+		dst:      dst,
+		checksum: crc32.NewIEEE(),
+		header:   header,
+	}
+	// the header is written as a placeholder and completed by Close
+	_, err := dst.Write(encodeHeader(header))
+	if err != nil {
+		return nil, err
+	}
+	w.writer = bufio.NewWriter(io.MultiWriter(dst, w.checksum))
+	return w, nil
+}
+
+func encodeHeader(h Header) []byte {
+	data := make([]byte, headerSize)
+	copy(data, magic[:])
+	data[4] = version
+	data[5] = byte(h.Channels)
+	copy(data[6:10], h.ChannelOrder)
+	binary.LittleEndian.PutUint16(data[10:], uint16(h.FrameRate))
+	binary.LittleEndian.PutUint32(data[12:], uint32(h.Pixels))
+	binary.LittleEndian.PutUint32(data[16:], uint32(h.Frames))
+	binary.LittleEndian.PutUint32(data[20:], uint32(h.Duration/time.Millisecond))
+	binary.LittleEndian.PutUint32(data[24:], h.Checksum)
+	return data
+}
+
+// WriteFrame appends a frame that contains the calibrated channels of all pixels
+func (w *Writer) WriteFrame(pixels []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(pixels) != w.header.frameSize() {
+		return fmt.Errorf("show: frame has %d bytes instead of %d", len(pixels), w.header.frameSize())
+	}
+
+	encoding, data := byte(encodingRaw), pixels
+	w.rle = encodeRLE(w.rle[:0], pixels, w.header.Channels)
+	if len(w.rle) < len(data) {
+		encoding, data = encodingRLE, w.rle
+	}
+	if w.previous != nil {
+		w.delta = encodeDelta(w.delta[:0], w.previous, pixels, w.header.Channels)
+		if len(w.delta) < len(data) {
+			encoding, data = encodingDelta, w.delta
+		}
+	}
+
+	var frameHeader [5]byte
+	frameHeader[0] = encoding
+	binary.LittleEndian.PutUint32(frameHeader[1:], uint32(len(data)))
+	if _, w.err = w.writer.Write(frameHeader[:]); w.err != nil {
+		return w.err
+	}
+	if _, w.err = w.writer.Write(data); w.err != nil {
+		return w.err
+	}
+	w.previous = append(w.previous[:0], pixels...)
+	w.header.Frames++
+	return nil
+}
+
+// encodeRLE appends the pixels as runs of identical pixels
+func encodeRLE(data, pixels []byte, channels int) []byte {
+	for position := 0; position < len(pixels); {
+		pixel := pixels[position : position+channels]
+		count := 1
+		for count < maxRun && position+(count+1)*channels <= len(pixels) &&
+			string(pixels[position+count*channels:position+(count+1)*channels]) == string(pixel) {
+			count++
+		}
+		data = append(data, byte(count), byte(count>>8))
+		data = append(data, pixel...)
+		position += count * channels
+	}
+	return data
+}
+
+// encodeDelta appends the runs of the pixels that changed compared to the previous ones
+func encodeDelta(data, previous, pixels []byte, channels int) []byte {
+	count := len(pixels) / channels
+	changed := func(i int) bool {
+		return string(previous[i*channels:(i+1)*channels]) != string(pixels[i*channels:(i+1)*channels])
+	}
+	for position := 0; position < count; {
+		unchanged := 0
+		for position+unchanged < count && !changed(position+unchanged) {
+			unchanged++
+		}
+		if position+unchanged == count {
+			// the remaining pixels don't need to be stored
+			break
+		}
+		if unchanged > maxRun {
+			unchanged = maxRun
+		}
+		position += unchanged
+		run := 0
+		for position+run < count && run < maxRun && changed(position+run) {
+			run++
+		}
+		data = append(data, byte(unchanged), byte(unchanged>>8), byte(run), byte(run>>8))
+		data = append(data, pixels[position*channels:(position+run)*channels]...)
+		position += run
+	}
+	return data
+}
+
+// Close completes the header of the show. It doesn't close the destination.
+// The header that has been written is returned.
+func (w *Writer) Close() (Header, error) {
+	if w.err != nil {
+		return w.header, w.err
+	}
+	if w.err = w.writer.Flush(); w.err != nil {
+		return w.header, w.err
+	}
+	if w.header.Duration == 0 {
+		w.header.Duration = time.Duration(w.header.Frames) * time.Second / time.Duration(w.header.FrameRate)
+	}
+	// the duration is stored in milliseconds
+	w.header.Duration = w.header.Duration.Truncate(time.Millisecond)
+	w.header.Checksum = w.checksum.Sum32()
+
+	end, err := w.dst.Seek(0, io.SeekCurrent)
+	if err != nil {
+		w.err = err
+		return w.header, err
+	}
+	if _, w.err = w.dst.Seek(0, io.SeekStart); w.err != nil {
+		return w.header, w.err
+	}
+	if _, w.err = w.dst.Write(encodeHeader(w.header)); w.err != nil {
+		return w.header, w.err
+	}
+	_, w.err = w.dst.Seek(end, io.SeekStart)
+	return w.header, w.err
+}
@@ -0,0 +1,63 @@
+package streamer
+
+import (
+	"io"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+const adalightHeaderLength = 6
+
+// AdalightStreamer sends frames in the format of the Adalight firmware that is commonly used on microcontrollers
+// which are connected over USB. Every frame starts with the magic word "Ada", followed by the number of pixels
+// and a checksum of it.
+type AdalightStreamer struct {
+	destination io.Writer
+	calibrated
+	buffer []byte // reused between frames
+	mutex  sync.Mutex
+}
+
+// NewAdalight creates a new Adalight streamer that writes to the destination which is usually a serial device.
+// The firmware writes the values directly to the LEDs so the streamer performs the gamma correction.
+func NewAdalight(dst io.Writer) *AdalightStreamer {
+	return &AdalightStreamer{
+		destination: dst,
+		calibrated:  calibrated{defaultGamma: ProtocolAdalight.DefaultGamma()},
+	}
+}
+
+func (s *AdalightStreamer) SetDestination(writer io.Writer) {
+	s.mutex.Lock()
+	s.destination = writer
+	s.mutex.Unlock()
+}
+
+func (s *AdalightStreamer) Stream(frame scanner.Frame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// the header can't describe empty frames
+	if s.destination == nil || len(frame.Pixels) == 0 {
+		return
+	}
+
+	cal := s.calibrator()
+	packet := resizeBuffer(s.buffer, adalightHeaderLength+cal.channels()*len(frame.Pixels))
+	s.buffer = packet
+	count := len(frame.Pixels) - 1 // the header contains the number of pixels minus one
+	packet[0] = 'A'
+	packet[1] = 'd'
+	packet[2] = 'a'
+	packet[3] = byte(count >> 8)
+	packet[4] = byte(count)
+	packet[5] = packet[3] ^ packet[4] ^ 0x55 // checksum
+	data := packet[adalightHeaderLength:]
+	for _, pixel := range frame.Pixels {
+		data = data[cal.write(data, pixel):]
+	}
+	_, err := s.destination.Write(packet)
+	if err != nil {
+		streamingErrors.log(err)
+	}
+}
@@ -0,0 +1,149 @@
+package streamer
+
+import (
+	"encoding/binary"
+	"image/color"
+	"io"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// ArtNetPort is the UDP port that is used by Art-Net
+const ArtNetPort = 6454
+
+const (
+	artNetMaxChannels      = 512
+	artNetHeaderLength     = 18
+	artNetProtocolVersion  = 14
+	artNetOpDmx            = 0x5000
+	artNetOpSync           = 0x5200
+	artNetMaxPortAddress   = 0x7fff
+	artNetSyncPacketLength = 14
+)
+
+// ArtNetStreamer sends frames as ArtDmx packets using the Art-Net protocol.
+// Every pixel takes up three or four channels depending on the calibration and frames that don't fit into one universe are split across multiple
+// consecutive port addresses. After all universes of a frame have been sent an ArtSync packet is sent so that
+// receivers output all universes at the same time.
+type ArtNetStreamer struct {
+	destination io.Writer
+	calibrated
+
+	Net               byte   // the net of the first pixel between 0 and 127
+	SubNet            byte   // the sub-net of the first pixel between 0 and 15
+	Universe          byte   // the universe of the first pixel between 0 and 15
+	PixelsPerUniverse int    // the maximum number of pixels in one universe
+	DisableSync       bool   // if true no ArtSync packets will be sent
+	sequence          byte   // the sequence number of the last frame
+	buffer            []byte // reused between packets
+	mutex             sync.Mutex
+}
+
+// NewArtNet creates a new Art-Net streamer that writes its packets to the destination.
+// By default the first pixel is in universe 0:0:0 and every universe contains 170 pixels.
+func NewArtNet(dst io.Writer) *ArtNetStreamer {
+	return &ArtNetStreamer{
+		destination:       dst,
+		PixelsPerUniverse: artNetMaxChannels / 3,
+		calibrated:        calibrated{defaultGamma: ProtocolArtNet.DefaultGamma()},
+	}
+}
+
+func (s *ArtNetStreamer) SetDestination(writer io.Writer) {
+	s.mutex.Lock()
+	s.destination = writer
+	s.mutex.Unlock()
+}
+
+// PortAddress returns the 15 bit port address that is made up of the net, sub-net and universe of the first pixel
+func (s *ArtNetStreamer) PortAddress() uint16 {
+	return uint16(s.Net&0x7f)<<8 | uint16(s.SubNet&0x0f)<<4 | uint16(s.Universe&0x0f)
+}
+
+func (s *ArtNetStreamer) Stream(frame scanner.Frame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.destination == nil {
+		return
+	}
+
+	cal := s.calibrator()
+	pixelsPerUniverse := s.PixelsPerUniverse
+	if pixelsPerUniverse <= 0 || pixelsPerUniverse > artNetMaxChannels/cal.channels() {
+		pixelsPerUniverse = artNetMaxChannels / cal.channels()
+	}
+
+	// the sequence number 0 disables reordering on the receiver so it is skipped
+	s.sequence++
+	if s.sequence == 0 {
+		s.sequence = 1
+	}
+
+	address := s.PortAddress()
+	pixels := frame.Pixels
+	for len(pixels) > 0 {
+		count := pixelsPerUniverse
+		if count > len(pixels) {
+			count = len(pixels)
+		}
+		_, err := s.destination.Write(s.buildDmxPacket(cal, address, pixels[:count]))
+		if err != nil {
+			streamingErrors.log(err)
+		}
+		pixels = pixels[count:]
+		address = (address + 1) & artNetMaxPortAddress
+	}
+
+	if !s.DisableSync {
+		_, err := s.destination.Write(s.buildSyncPacket())
+		if err != nil {
+			streamingErrors.log(err)
+		}
+	}
+}
+
+// writeArtNetHeader writes the identifier, op code and protocol version that every Art-Net packet starts with
+func writeArtNetHeader(packet []byte, opCode uint16) {
+	copy(packet[0:8], "Art-Net\x00")
+	binary.LittleEndian.PutUint16(packet[8:], opCode)
+	binary.BigEndian.PutUint16(packet[10:], artNetProtocolVersion)
+}
+
+// buildDmxPacket creates an ArtDmx packet for the port address that contains the pixels.
+// The returned slice is only valid until the next call.
+func (s *ArtNetStreamer) buildDmxPacket(cal *calibrator, address uint16, pixels []color.RGBA64) []byte {
+	size := cal.channels() * len(pixels)
+	channels := size
+	if channels%2 != 0 {
+		channels++ // the length of the data has to be even
+	}
+	packet := resizeBuffer(s.buffer, artNetHeaderLength+channels)
+	s.buffer = packet
+
+	writeArtNetHeader(packet, artNetOpDmx)
+	packet[12] = s.sequence
+	packet[13] = 0                  // physical input port
+	packet[14] = byte(address)      // sub-net and universe
+	packet[15] = byte(address >> 8) // net
+	binary.BigEndian.PutUint16(packet[16:], uint16(channels))
+
+	data := packet[artNetHeaderLength:]
+	for _, pixel := range pixels {
+		data = data[cal.write(data, pixel):]
+	}
+	if channels > size {
+		packet[len(packet)-1] = 0 // padding
+	}
+	return packet
+}
+
+// buildSyncPacket creates an ArtSync packet. The returned slice is only valid until the next call.
+func (s *ArtNetStreamer) buildSyncPacket() []byte {
+	packet := resizeBuffer(s.buffer, artNetSyncPacketLength)
+	s.buffer = packet
+	writeArtNetHeader(packet, artNetOpSync)
+	packet[12] = 0 // aux1
+	packet[13] = 0 // aux2
+	return packet
+}
@@ -0,0 +1,182 @@
+package streamer
+
+import (
+	"encoding/binary"
+	"image/color"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// artNetPacket is a packet that has been received by receiveArtNet
+type artNetPacket struct {
+	opCode   uint16
+	sequence byte
+	address  uint16
+	data     []byte
+}
+
+// receiveArtNet reads count packets from the connection and parses their Art-Net header
+func receiveArtNet(t *testing.T, conn net.PacketConn, count int) []artNetPacket {
+	t.Helper()
+	var packets []artNetPacket
+	buffer := make([]byte, 1024)
+	for len(packets) < count {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("received %d of %d packets: %v", len(packets), count, err)
+		}
+		data := buffer[:n]
+		if n < 12 || string(data[:8]) != "Art-Net\x00" {
+			t.Fatalf("packet %d is not an Art-Net packet: %x", len(packets), data)
+		}
+		if version := binary.BigEndian.Uint16(data[10:]); version != artNetProtocolVersion {
+			t.Fatalf("packet %d has protocol version %d", len(packets), version)
+		}
+		packet := artNetPacket{opCode: binary.LittleEndian.Uint16(data[8:])}
+		if packet.opCode == artNetOpDmx {
+			packet.sequence = data[12]
+			packet.address = uint16(data[15])<<8 | uint16(data[14])
+			length := int(binary.BigEndian.Uint16(data[16:]))
+			if length != n-artNetHeaderLength {
+				t.Fatalf("packet %d declares %d channels but contains %d", len(packets), length, n-artNetHeaderLength)
+			}
+			packet.data = append([]byte(nil), data[artNetHeaderLength:]...)
+		}
+		packets = append(packets, packet)
+	}
+	return packets
+}
+
+func TestArtNetReceiver(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	config := DefaultOutputConfig()
+	config.Protocol = ProtocolArtNet
+	config.Address = "127.0.0.1"
+	config.Port = conn.LocalAddr().(*net.UDPAddr).Port
+	config.ArtNetNet = 1
+	config.ArtNetSubNet = 2
+	config.ArtNetUniverse = 15
+	str, err := config.NewStreamer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, closer, err := config.OpenDestination()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	str.SetDestination(writer)
+
+	pixels := make([]color.RGBA64, 200)
+	for i := range pixels {
+		if i%2 == 0 {
+			pixels[i].R = 0xffff
+		}
+		if i%3 == 0 {
+			pixels[i].B = 0xffff
+		}
+		pixels[i].A = 0xffff
+	}
+	str.Stream(scanner.Frame{Pixels: pixels})
+	packets := receiveArtNet(t, conn, 3)
+
+	// 170 pixels fit into the first universe, the rest continue in the next port address
+	expected := []struct {
+		address uint16
+		pixels  []color.RGBA64
+	}{
+		{0x012f, pixels[:170]},
+		{0x0130, pixels[170:]},
+	}
+	for i, universe := range expected {
+		packet := packets[i]
+		if packet.opCode != artNetOpDmx {
+			t.Fatalf("packet %d has op code %#x instead of ArtDmx", i, packet.opCode)
+		}
+		if packet.address != universe.address {
+			t.Errorf("packet %d has port address %#x instead of %#x", i, packet.address, universe.address)
+		}
+		if packet.sequence != 1 {
+			t.Errorf("packet %d has sequence %d instead of 1", i, packet.sequence)
+		}
+		if len(packet.data) != 3*len(universe.pixels) {
+			t.Fatalf("packet %d contains %d channels instead of %d", i, len(packet.data), 3*len(universe.pixels))
+		}
+		for p, pixel := range universe.pixels {
+			channels := []byte{byte(pixel.R >> 8), byte(pixel.G >> 8), byte(pixel.B >> 8)}
+			if string(packet.data[3*p:3*p+3]) != string(channels) {
+				t.Fatalf("pixel %d of packet %d is %v instead of %v", p, i, packet.data[3*p:3*p+3], channels)
+			}
+		}
+	}
+	if packets[2].opCode != artNetOpSync {
+		t.Errorf("the last packet has op code %#x instead of ArtSync", packets[2].opCode)
+	}
+}
+
+func TestArtNetDisableSync(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	config := DefaultOutputConfig()
+	config.Protocol = ProtocolArtNet
+	config.Port = conn.LocalAddr().(*net.UDPAddr).Port
+	config.ArtNetDisableSync = true
+	config.PixelsPerUniverse = 10
+	str, err := config.NewStreamer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, closer, err := config.OpenDestination()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	str.SetDestination(writer)
+
+	str.Stream(scanner.Frame{Pixels: make([]color.RGBA64, 15)})
+	str.Stream(scanner.Frame{Pixels: make([]color.RGBA64, 15)})
+	packets := receiveArtNet(t, conn, 4)
+	for i, packet := range packets {
+		if packet.opCode != artNetOpDmx {
+			t.Fatalf("packet %d has op code %#x although sync is disabled", i, packet.opCode)
+		}
+	}
+	// two universes per frame with 10 and 5 pixels, the second one padded to an even length
+	for i, length := range []int{30, 16, 30, 16} {
+		if len(packets[i].data) != length {
+			t.Errorf("packet %d contains %d channels instead of %d", i, len(packets[i].data), length)
+		}
+	}
+	if packets[2].sequence != 2 {
+		t.Errorf("the second frame has sequence %d instead of 2", packets[2].sequence)
+	}
+}
+
+func TestArtNetConfigValidation(t *testing.T) {
+	for _, modify := range []func(*OutputConfig){
+		func(c *OutputConfig) { c.ArtNetNet = 128 },
+		func(c *OutputConfig) { c.ArtNetSubNet = 16 },
+		func(c *OutputConfig) { c.ArtNetUniverse = -1 },
+		func(c *OutputConfig) { c.PixelsPerUniverse = -1 },
+	} {
+		config := DefaultOutputConfig()
+		config.Protocol = ProtocolArtNet
+		modify(&config)
+		if _, err := config.NewStreamer(); err == nil {
+			t.Errorf("config %+v has been accepted", config)
+		}
+	}
+}
@@ -19,15 +19,16 @@ type Streamer interface {
 
 type BasicStreamer struct {
 	destination io.Writer
-	gamma       float64
-	Version     int
-	mutex       sync.Mutex
+	calibrated
+	Version int
+	buffer  []byte // reused between frames
+	mutex   sync.Mutex
 }
 
-func NewBasic(dst io.Writer) BasicStreamer {
-	return BasicStreamer{
+func NewBasic(dst io.Writer) *BasicStreamer {
+	return &BasicStreamer{
 		destination: dst,
-		gamma:       2.2,
+		calibrated:  calibrated{defaultGamma: ProtocolBasic.DefaultGamma()},
 		Version:     1,
 	}
 }
@@ -38,7 +39,7 @@ func (s *BasicStreamer) SetDestination(writer io.Writer) {
 	s.mutex.Unlock()
 }
 
-func (s BasicStreamer) Stream(frame scanner.Frame) {
+func (s *BasicStreamer) Stream(frame scanner.Frame) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if s.destination == nil {
@@ -52,8 +53,10 @@ func (s BasicStreamer) Stream(frame scanner.Frame) {
 	}
 }
 
-func (s BasicStreamer) streamVersion1(frame scanner.Frame) {
+func (s *BasicStreamer) streamVersion1(frame scanner.Frame) {
 	const maxPixelsPerPacket = 300
+	cal := s.calibrator()
+	var pixelData [4]byte
 	// split the data in multiple packets
 	packetCount := int(math.Ceil(float64(len(frame.Pixels)) / maxPixelsPerPacket))
 	for i := 0; i < packetCount; i++ {
@@ -64,7 +67,7 @@ func (s BasicStreamer) streamVersion1(frame scanner.Frame) {
 		}
 		//fmt.Printf("packet %d (%d pixels)\n", i, pixelCount)
 
-		packet := bytes.NewBuffer(make([]byte, 0, 6+3*pixelCount))
+		packet := bytes.NewBuffer(s.buffer[:0])
 		packet.WriteByte(1) // packet type
 		if i == 0 {
 			// write header in first packet
@@ -72,21 +75,17 @@ func (s BasicStreamer) streamVersion1(frame scanner.Frame) {
 		} else {
 			packet.WriteByte(0) // header length
 		}
-		binary.Write(packet, binary.LittleEndian, uint16(i*maxPixelsPerPacket)) // pixel offset
-		binary.Write(packet, binary.LittleEndian, uint16(pixelCount*3))         // data length
-		for c := 0; c < pixelCount; c++ {
-			r, g, b, _ := frame.Pixels[i*maxPixelsPerPacket+c].RGBA()
-			packet.Write([]byte{
-				byte(math.Pow(float64(r)/0xffff, s.gamma) * 0xff),
-				byte(math.Pow(float64(g)/0xffff, s.gamma) * 0xff),
-				byte(math.Pow(float64(b)/0xffff, s.gamma) * 0xff),
-			})
+		binary.Write(packet, binary.LittleEndian, uint16(i*maxPixelsPerPacket))      // pixel offset
+		binary.Write(packet, binary.LittleEndian, uint16(pixelCount*cal.channels())) // data length
+		for _, pixel := range frame.Pixels[i*maxPixelsPerPacket : i*maxPixelsPerPacket+pixelCount] {
+			packet.Write(pixelData[:cal.write(pixelData[:], pixel)])
 		}
 
-		_, err := io.Copy(s.destination, packet)
+		_, err := s.destination.Write(packet.Bytes())
 		if err != nil {
-			logrus.Errorf("streaming error: %v", err)
+			streamingErrors.log(err)
 		}
+		s.buffer = packet.Bytes() // keep the memory for the next packet
 	}
 }
 
@@ -105,18 +104,26 @@ func writeHeader(packet *bytes.Buffer, frame scanner.Frame) {
 	io.Copy(packet, buffer)
 }
 
-func (s BasicStreamer) streamVersion0(frame scanner.Frame) {
-	var data = make([]byte, 1+3*len(frame.Pixels))
+func (s *BasicStreamer) streamVersion0(frame scanner.Frame) {
+	cal := s.calibrator()
+	data := resizeBuffer(s.buffer, 1+cal.channels()*len(frame.Pixels))
+	s.buffer = data
 	data[0] = 0
-	for i, pixel := range frame.Pixels {
-		r, g, b, _ := pixel.RGBA()
-		// map from 0xffff to 0xff and apply gamma correction
-		data[i*3+1] = byte(math.Pow(float64(r)/0xffff, s.gamma) * 0xff)
-		data[i*3+2] = byte(math.Pow(float64(g)/0xffff, s.gamma) * 0xff)
-		data[i*3+3] = byte(math.Pow(float64(b)/0xffff, s.gamma) * 0xff)
+	pixelData := data[1:]
+	for _, pixel := range frame.Pixels {
+		// map from 0xffff to 0xff and apply the calibration
+		pixelData = pixelData[cal.write(pixelData, pixel):]
 	}
 	_, err := s.destination.Write(data)
 	if err != nil {
-		logrus.Errorf("streaming error: %v", err)
+		streamingErrors.log(err)
 	}
 }
+
+// resizeBuffer returns a byte slice with the size that reuses the memory of buffer if possible
+func resizeBuffer(buffer []byte, size int) []byte {
+	if cap(buffer) < size {
+		return make([]byte, size)
+	}
+	return buffer[:size]
+}
@@ -0,0 +1,299 @@
+package streamer
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+// Calibration describes how the colors of a frame are converted to the values that are sent to a device.
+// The corrections are applied in the following order: white point, white extraction, gamma or lookup table and
+// finally the channel order.
+type Calibration struct {
+	// ChannelOrder is the order in which the channels are sent like "RGB", "GRB" or "GRBW".
+	// If it contains a W the white component of every color is moved into a separate white channel.
+	ChannelOrder string `json:"channelOrder"`
+	// Gamma contains the gamma of the red, green, blue and white channel. A value of 0 uses the default gamma of
+	// the streamer which depends on whether the device performs its own gamma correction.
+	Gamma [4]float64 `json:"gamma"`
+	// LookupTables can replace the gamma of the red, green, blue and white channel with a custom curve.
+	// A table contains evenly spaced output values between 0 and 1, values in between are interpolated linearly.
+	LookupTables [4][]float64 `json:"lookupTables,omitempty"`
+	// WhitePoint contains factors between 0 and 1 for the red, green and blue channel that are used to
+	// correct the white point of the LEDs. A factor of 0 is treated as 1, so a white point of zeros applies no correction.
+	WhitePoint [3]float64 `json:"whitePoint"`
+	// Temperature is the color temperature in kelvin that white should have. A value of 0 disables the correction.
+	Temperature float64 `json:"temperature"`
+}
+
+// DefaultCalibration returns a Calibration that doesn't change the colors
+func DefaultCalibration() Calibration {
+	return Calibration{ChannelOrder: "RGB"}
+}
+
+const (
+	channelRed = iota
+	channelGreen
+	channelBlue
+	channelWhite
+)
+
+// parseChannelOrder returns the channel for every letter of the order.
+// The order needs to contain R, G and B exactly once and can contain a W.
+func parseChannelOrder(order string) ([]int, error) {
+	if order == "" {
+		return []int{channelRed, channelGreen, channelBlue}, nil
+	}
+	var channels []int
+	seen := make(map[rune]bool)
+	for _, letter := range strings.ToUpper(order) {
+		if seen[letter] {
+			return nil, fmt.Errorf("channel order %q contains %c more than once", order, letter)
+		}
+		seen[letter] = true
+		switch letter {
+		case 'R':
+			channels = append(channels, channelRed)
+		case 'G':
+			channels = append(channels, channelGreen)
+		case 'B':
+			channels = append(channels, channelBlue)
+		case 'W':
+			channels = append(channels, channelWhite)
+		default:
+			return nil, fmt.Errorf("channel order %q contains unknown channel %c", order, letter)
+		}
+	}
+	if !seen['R'] || !seen['G'] || !seen['B'] {
+		return nil, fmt.Errorf("channel order %q needs to contain R, G and B", order)
+	}
+	return channels, nil
+}
+
+// Validate returns an error if the calibration can't be used
+func (c Calibration) Validate() error {
+	_, err := parseChannelOrder(c.ChannelOrder)
+	if err != nil {
+		return err
+	}
+	for i, gamma := range c.Gamma {
+		if gamma < 0 || math.IsNaN(gamma) || math.IsInf(gamma, 0) {
+			return fmt.Errorf("invalid gamma %v for channel %d", gamma, i)
+		}
+	}
+	for i, table := range c.LookupTables {
+		for _, value := range table {
+			if !(value >= 0 && value <= 1) {
+				return fmt.Errorf("lookup table of channel %d contains %v which is not between 0 and 1", i, value)
+			}
+		}
+	}
+	for i, factor := range c.WhitePoint {
+		// the negated comparison also rejects NaN
+		if !(factor >= 0 && factor <= 1) {
+			return fmt.Errorf("white point factor %v for channel %d needs to be between 0 and 1", factor, i)
+		}
+	}
+	if c.Temperature < 0 || math.IsNaN(c.Temperature) || math.IsInf(c.Temperature, 0) {
+		return fmt.Errorf("invalid color temperature %v", c.Temperature)
+	}
+	return nil
+}
+
+// whitePointFactors returns the combined factors of the white point and color temperature
+func (c Calibration) whitePointFactors() [3]float64 {
+	factors := [3]float64{1, 1, 1}
+	for i, factor := range c.WhitePoint {
+		if factor > 0 {
+			factors[i] = factor
+		}
+	}
+	if c.Temperature > 0 {
+		temperature := temperatureFactors(c.Temperature)
+		for i := range factors {
+			factors[i] *= temperature[i]
+		}
+	}
+	return factors
+}
+
+// temperatureFactors returns the factors for the red, green and blue channel that tint white in the color of the
+// temperature. The factors are relative to a temperature of 6500K which is the white point of sRGB.
+func temperatureFactors(kelvin float64) [3]float64 {
+	color := blackBodyColor(kelvin)
+	reference := blackBodyColor(6500)
+	factors := [3]float64{color[0] / reference[0], color[1] / reference[1], color[2] / reference[2]}
+	max := math.Max(factors[0], math.Max(factors[1], factors[2]))
+	for i := range factors {
+		factors[i] /= max
+	}
+	return factors
+}
+
+// blackBodyColor approximates the color of a black body with the temperature in kelvin.
+// The approximation is based on the work of Tanner Helland and valid between 1000K and 40000K.
+func blackBodyColor(kelvin float64) [3]float64 {
+	t := math.Min(40000, math.Max(1000, kelvin)) / 100
+	clamp := func(v float64) float64 {
+		return math.Min(255, math.Max(0, v)) / 255
+	}
+
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	if t >= 66 {
+		b = 255
+	} else if t <= 19 {
+		b = 0
+	} else {
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	return [3]float64{clamp(r), clamp(g), clamp(b)}
+}
+
+// calibrator applies a Calibration to the pixels of a frame
+type calibrator struct {
+	order  []int     // the channel for every byte of a pixel
+	white  bool      // true if the white component is moved into a separate channel
+	scale  [3]uint32 // white point factors of the red, green and blue channel as fixed point numbers with 16 bits
+	tables [4]*lookupTable
+}
+
+// newCalibrator prepares the calibration. Channels without a gamma use the defaultGamma.
+func newCalibrator(c Calibration, defaultGamma float64) (*calibrator, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	order, _ := parseChannelOrder(c.ChannelOrder)
+	cal := &calibrator{order: order}
+	for _, channel := range order {
+		if channel == channelWhite {
+			cal.white = true
+		}
+	}
+
+	factors := c.whitePointFactors()
+	for i, factor := range factors {
+		cal.scale[i] = uint32(math.Round(factor * 0x10000))
+	}
+
+	for i := range cal.tables {
+		switch {
+		case len(c.LookupTables[i]) > 0:
+			cal.tables[i] = newCurveTable(c.LookupTables[i])
+		case c.Gamma[i] > 0:
+			cal.tables[i] = newGammaTable(c.Gamma[i])
+		default:
+			cal.tables[i] = newGammaTable(defaultGamma)
+		}
+	}
+	return cal, nil
+}
+
+// channels returns the number of bytes per pixel
+func (c *calibrator) channels() int {
+	return len(c.order)
+}
+
+// values returns the calibrated values of the red, green, blue and white channel.
+// If extractWhite is false the white channel is always 0.
+func (c *calibrator) values(pixel color.RGBA64, extractWhite bool) [4]byte {
+	r := uint32(pixel.R) * c.scale[0] >> 16
+	g := uint32(pixel.G) * c.scale[1] >> 16
+	b := uint32(pixel.B) * c.scale[2] >> 16
+
+	var w uint32
+	if extractWhite {
+		w = r
+		if g < w {
+			w = g
+		}
+		if b < w {
+			w = b
+		}
+		r, g, b = r-w, g-w, b-w
+	}
+
+	return [4]byte{c.tables[channelRed][r], c.tables[channelGreen][g], c.tables[channelBlue][b], c.tables[channelWhite][w]}
+}
+
+// write writes the channels of the pixel in the calibrated order to data and returns the number of bytes written
+func (c *calibrator) write(data []byte, pixel color.RGBA64) int {
+	values := c.values(pixel, c.white)
+	for i, channel := range c.order {
+		data[i] = values[channel]
+	}
+	return len(c.order)
+}
+
+// writeRGB works like write but skips the white channel for devices that don't support one
+func (c *calibrator) writeRGB(data []byte, pixel color.RGBA64) int {
+	values := c.values(pixel, false)
+	n := 0
+	for _, channel := range c.order {
+		if channel != channelWhite {
+			data[n] = values[channel]
+			n++
+		}
+	}
+	return n
+}
+
+// calibrated is embedded into streamers to allow changing their Calibration at any time.
+// Until a calibration has been set the DefaultCalibration is used.
+type calibrated struct {
+	defaultGamma float64      // the gamma of channels that don't specify their own
+	current      atomic.Value // *calibrator
+}
+
+// SetCalibration changes the calibration that is applied to all following frames.
+// If the calibration is invalid an error is returned and the previous calibration stays active.
+func (c *calibrated) SetCalibration(calibration Calibration) error {
+	cal, err := newCalibrator(calibration, c.defaultGamma)
+	if err != nil {
+		return err
+	}
+	c.current.Store(cal)
+	return nil
+}
+
+func (c *calibrated) calibrator() *calibrator {
+	if cal, ok := c.current.Load().(*calibrator); ok {
+		return cal
+	}
+	cal, _ := newCalibrator(DefaultCalibration(), c.defaultGamma)
+	c.current.Store(cal)
+	return cal
+}
+
+// Calibrator applies a Calibration to single pixels for formats that are not streamed, like pre-rendered shows
+type Calibrator struct {
+	cal *calibrator
+}
+
+// NewCalibrator prepares the calibration. Channels without a gamma use the defaultGamma.
+func (c Calibration) NewCalibrator(defaultGamma float64) (*Calibrator, error) {
+	cal, err := newCalibrator(c, defaultGamma)
+	if err != nil {
+		return nil, err
+	}
+	return &Calibrator{cal: cal}, nil
+}
+
+// Channels returns the number of bytes per pixel
+func (c *Calibrator) Channels() int {
+	return c.cal.channels()
+}
+
+// Write writes the calibrated channels of the pixel to data and returns the number of bytes written
+func (c *Calibrator) Write(data []byte, pixel color.RGBA64) int {
+	return c.cal.write(data, pixel)
+}
@@ -0,0 +1,108 @@
+package streamer
+
+import (
+	"encoding/binary"
+	"image/color"
+	"io"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// DDPPort is the UDP port that is used by the Distributed Display Protocol
+const DDPPort = 4048
+
+const (
+	ddpHeaderLength  = 10
+	ddpMaxDataLength = 1440 // 480 RGB pixels, the packets still fit into a standard ethernet frame
+	ddpVersion1      = 0x40
+	ddpFlagPush      = 0x01
+	ddpTypeRGB24     = 0x0b // RGB with 8 bits per channel
+	ddpTypeRGBW32    = 0x1b // RGBW with 8 bits per channel
+	ddpIDDisplay     = 0x01 // the default output device
+)
+
+// DDPStreamer sends frames using the Distributed Display Protocol that is supported by WLED, xLights and many
+// other firmwares. Unlike DRGB it has no limit on the number of pixels, large frames are split into multiple
+// packets and only the last packet has the push flag set, which tells the device to display the frame.
+type DDPStreamer struct {
+	destination io.Writer
+	calibrated
+	sequence byte   // the sequence number of the last frame between 1 and 15
+	buffer   []byte // reused between packets
+	mutex    sync.Mutex
+}
+
+// NewDDP creates a new DDP streamer that writes its packets to the destination.
+// Like the WLEDStreamer it does not perform gamma correction as that is usually handled by the device itself.
+func NewDDP(dst io.Writer) *DDPStreamer {
+	return &DDPStreamer{
+		destination: dst,
+		calibrated:  calibrated{defaultGamma: ProtocolDDP.DefaultGamma()},
+	}
+}
+
+func (s *DDPStreamer) SetDestination(writer io.Writer) {
+	s.mutex.Lock()
+	s.destination = writer
+	s.mutex.Unlock()
+}
+
+func (s *DDPStreamer) Stream(frame scanner.Frame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.destination == nil {
+		return
+	}
+
+	// the sequence number 0 means that it is not used so it cycles through 1 to 15
+	s.sequence = s.sequence%15 + 1
+
+	cal := s.calibrator()
+	pixelsPerPacket := ddpMaxDataLength / cal.channels()
+	pixels := frame.Pixels
+	offset := 0 // the offset of the first pixel of the packet in bytes
+	for {
+		count := pixelsPerPacket
+		if count > len(pixels) {
+			count = len(pixels)
+		}
+		push := count == len(pixels)
+		_, err := s.destination.Write(s.buildPacket(cal, offset, pixels[:count], push))
+		if err != nil {
+			streamingErrors.log(err)
+		}
+		if push {
+			break
+		}
+		pixels = pixels[count:]
+		offset += count * cal.channels()
+	}
+}
+
+// buildPacket creates a packet that contains the pixels at the offset.
+// The returned slice is only valid until the next call.
+func (s *DDPStreamer) buildPacket(cal *calibrator, offset int, pixels []color.RGBA64, push bool) []byte {
+	size := cal.channels() * len(pixels)
+	packet := resizeBuffer(s.buffer, ddpHeaderLength+size)
+	s.buffer = packet
+
+	packet[0] = ddpVersion1
+	if push {
+		packet[0] |= ddpFlagPush
+	}
+	packet[1] = s.sequence
+	packet[2] = ddpTypeRGB24
+	if cal.white {
+		packet[2] = ddpTypeRGBW32
+	}
+	packet[3] = ddpIDDisplay
+	binary.BigEndian.PutUint32(packet[4:], uint32(offset))
+	binary.BigEndian.PutUint16(packet[8:], uint16(size))
+
+	data := packet[ddpHeaderLength:]
+	for _, pixel := range pixels {
+		data = data[cal.write(data, pixel):]
+	}
+	return packet
+}
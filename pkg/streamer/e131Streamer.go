@@ -0,0 +1,240 @@
+package streamer
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// E131Port is the UDP port that is used by E1.31 (sACN)
+const E131Port = 5568
+
+// E131MaxUniverse is the highest universe that can be used for data
+const E131MaxUniverse = 63999
+
+const (
+	e131MaxChannels    = 512 // number of DMX channels in one universe
+	e131HeaderLength   = 126 // length of all layers up to and including the DMX start code
+	e131SourceNameSize = 64
+)
+
+// E131Streamer sends frames using the E1.31 (Streaming ACN) protocol that is supported by most professional pixel
+// controllers. Every pixel takes up three or four channels depending on the calibration and frames that don't fit into one universe are split across
+// multiple consecutive universes. A pixel is never split between two universes.
+//
+// By default all packets are written to the destination which should be a UDP connection to the controller.
+// If multicast is enabled the packets of each universe are sent to the multicast group of that universe instead.
+type E131Streamer struct {
+	destination io.Writer
+	calibrated
+
+	StartUniverse     uint16   // the universe of the first pixel, valid universes are 1 to 63999
+	StartChannel      int      // the channel of the first pixel in the start universe beginning at 1
+	PixelsPerUniverse int      // the maximum number of pixels in one universe
+	Priority          byte     // the priority of the data between 0 and 200 where higher values take precedence
+	SourceName        string   // a name that identifies this source to the receivers
+	CID               [16]byte // a UUID that uniquely identifies this source
+	multicast         bool
+	multicastWriters  map[uint16]io.Writer // connections to the multicast groups of each universe
+	multicastHealth   *outputHealth        // records the writes to the multicast groups if it is set
+	sequences         map[uint16]byte      // the last sequence number of each universe
+	buffer            []byte               // reused between packets
+	mutex             sync.Mutex
+}
+
+// NewE131 creates a new E1.31 streamer that writes its packets to the destination.
+// The streamer starts in universe 1 and gets a random CID.
+func NewE131(dst io.Writer) *E131Streamer {
+	s := &E131Streamer{
+		destination:       dst,
+		StartUniverse:     1,
+		StartChannel:      1,
+		PixelsPerUniverse: e131MaxChannels / 3,
+		Priority:          100,
+		SourceName:        "Firefly",
+		multicastWriters:  make(map[uint16]io.Writer),
+		sequences:         make(map[uint16]byte),
+		calibrated:        calibrated{defaultGamma: ProtocolE131.DefaultGamma()},
+	}
+	_, err := rand.Read(s.CID[:])
+	if err != nil {
+		logrus.Errorf("e1.31 streamer could not generate a CID: %v", err)
+	}
+	// mark the CID as a random version 4 UUID
+	s.CID[6] = s.CID[6]&0x0f | 0x40
+	s.CID[8] = s.CID[8]&0x3f | 0x80
+	return s
+}
+
+// E131MulticastAddress returns the address of the multicast group of the universe
+func E131MulticastAddress(universe uint16) string {
+	return fmt.Sprintf("239.255.%d.%d:%d", universe>>8, universe&0xff, E131Port)
+}
+
+func (s *E131Streamer) SetDestination(writer io.Writer) {
+	s.mutex.Lock()
+	s.destination = writer
+	s.mutex.Unlock()
+}
+
+// SetMulticast enables or disables sending the universes to their multicast groups instead of the destination
+func (s *E131Streamer) SetMulticast(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.multicast = enabled
+	if !enabled {
+		s.closeMulticastWriters()
+	}
+}
+
+// recordMulticastHealth records the writes to the multicast groups in the health of an output,
+// because they don't pass through the destination that the output wraps
+func (s *E131Streamer) recordMulticastHealth(health *outputHealth) {
+	s.mutex.Lock()
+	s.multicastHealth = health
+	s.mutex.Unlock()
+}
+
+func (s *E131Streamer) closeMulticastWriters() {
+	for universe, writer := range s.multicastWriters {
+		if closer, ok := writer.(io.Closer); ok {
+			closer.Close()
+		}
+		delete(s.multicastWriters, universe)
+	}
+}
+
+func (s *E131Streamer) Stream(frame scanner.Frame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.destination == nil && !s.multicast {
+		return
+	}
+
+	cal := s.calibrator()
+	channels := cal.channels()
+	pixelsPerUniverse := s.PixelsPerUniverse
+	if pixelsPerUniverse <= 0 || pixelsPerUniverse > e131MaxChannels/channels {
+		pixelsPerUniverse = e131MaxChannels / channels
+	}
+	channelOffset := s.StartChannel - 1 // the offset of the first pixel in the current universe
+	if channelOffset < 0 || channelOffset > e131MaxChannels-channels {
+		channelOffset = 0
+	}
+
+	universe := s.StartUniverse
+	if universe < 1 {
+		universe = 1
+	}
+	pixels := frame.Pixels
+	for len(pixels) > 0 {
+		if universe > E131MaxUniverse {
+			streamingErrors.log(fmt.Errorf("e1.31: %d pixels have not been sent because they exceed universe %d", len(pixels), E131MaxUniverse))
+			return
+		}
+		count := (e131MaxChannels - channelOffset) / channels
+		if count > pixelsPerUniverse {
+			count = pixelsPerUniverse
+		}
+		if count > len(pixels) {
+			count = len(pixels)
+		}
+
+		packet := s.buildPacket(cal, universe, channelOffset, pixels[:count])
+		writer, err := s.writerFor(universe)
+		if err == nil {
+			_, err = writer.Write(packet)
+		}
+		if err != nil {
+			streamingErrors.log(err)
+		}
+
+		pixels = pixels[count:]
+		channelOffset = 0
+		universe++
+	}
+}
+
+// writerFor returns the writer that the packets of the universe should be written to
+func (s *E131Streamer) writerFor(universe uint16) (io.Writer, error) {
+	if !s.multicast {
+		return s.destination, nil
+	}
+	writer, ok := s.multicastWriters[universe]
+	if !ok {
+		udpWriter, err := NewUDPWriter(E131MulticastAddress(universe))
+		if err != nil {
+			err = fmt.Errorf("could not connect to the multicast group of universe %d: %w", universe, err)
+			if s.multicastHealth != nil {
+				s.multicastHealth.record(err)
+			}
+			return nil, err
+		}
+		writer = udpWriter
+		s.multicastWriters[universe] = writer
+	}
+	if s.multicastHealth != nil {
+		return healthWriter{destination: writer, health: s.multicastHealth}, nil
+	}
+	return writer, nil
+}
+
+// buildPacket creates a data packet for the universe that contains the pixels beginning at the channel offset.
+// The returned slice is only valid until the next call.
+func (s *E131Streamer) buildPacket(cal *calibrator, universe uint16, channelOffset int, pixels []color.RGBA64) []byte {
+	channels := channelOffset + cal.channels()*len(pixels)
+	packet := resizeBuffer(s.buffer, e131HeaderLength+channels)
+	s.buffer = packet
+	for i := range packet {
+		packet[i] = 0
+	}
+
+	// root layer
+	binary.BigEndian.PutUint16(packet[0:], 0x0010) // preamble size
+	binary.BigEndian.PutUint16(packet[2:], 0x0000) // postamble size
+	copy(packet[4:16], "ASC-E1.17\x00\x00\x00")    // ACN packet identifier
+	binary.BigEndian.PutUint16(packet[16:], 0x7000|uint16(len(packet)-16))
+	binary.BigEndian.PutUint32(packet[18:], 0x00000004) // VECTOR_ROOT_E131_DATA
+	copy(packet[22:38], s.CID[:])
+
+	// framing layer
+	binary.BigEndian.PutUint16(packet[38:], 0x7000|uint16(len(packet)-38))
+	binary.BigEndian.PutUint32(packet[40:], 0x00000002) // VECTOR_E131_DATA_PACKET
+	name := s.SourceName
+	if len(name) > e131SourceNameSize-1 {
+		name = name[:e131SourceNameSize-1] // the name needs to be null terminated
+	}
+	copy(packet[44:44+e131SourceNameSize], name)
+	priority := s.Priority
+	if priority > 200 {
+		priority = 200
+	}
+	packet[108] = priority
+	binary.BigEndian.PutUint16(packet[109:], 0) // synchronization address
+	s.sequences[universe]++
+	packet[111] = s.sequences[universe]
+	packet[112] = 0 // options
+	binary.BigEndian.PutUint16(packet[113:], universe)
+
+	// DMP layer
+	binary.BigEndian.PutUint16(packet[115:], 0x7000|uint16(len(packet)-115))
+	packet[117] = 0x02                                           // VECTOR_DMP_SET_PROPERTY
+	packet[118] = 0xa1                                           // address type & data type
+	binary.BigEndian.PutUint16(packet[119:], 0x0000)             // first property address
+	binary.BigEndian.PutUint16(packet[121:], 0x0001)             // address increment
+	binary.BigEndian.PutUint16(packet[123:], uint16(1+channels)) // property value count including the start code
+	packet[125] = 0x00                                           // DMX start code
+
+	data := packet[e131HeaderLength+channelOffset:]
+	for _, pixel := range pixels {
+		data = data[cal.write(data, pixel):]
+	}
+	return packet
+}
@@ -9,29 +9,40 @@ import (
 	"github.com/omniskop/firefly/pkg/scanner"
 )
 
-func init() {
-	gob.Register(color.RGBA{})
-	gob.Register(color.RGBA64{})
-	gob.Register(color.NRGBA{})
-	gob.Register(color.NRGBA64{})
-	gob.Register(color.Gray{})
-	gob.Register(color.Gray16{})
-}
-
 type GobStreamer struct {
-	gamma   float64
+	calibrated
 	encoder *gob.Encoder
+	pixels  []color.RGBA64 // reused between frames
 }
 
+// NewGob creates a new streamer that encodes the frames using encoding/gob.
+// The gamma, lookup tables and white point of the calibration are applied to the colors of the frames.
+// The channel order and white channel are not because the receiver gets the channels by name.
 func NewGob(dst io.Writer) *GobStreamer {
 	return &GobStreamer{
-		gamma:   2.2,
-		encoder: gob.NewEncoder(dst),
+		calibrated: calibrated{defaultGamma: 2.2},
+		encoder:    gob.NewEncoder(dst),
 	}
 }
 
 func (gs *GobStreamer) Stream(frame scanner.Frame) {
-	err := gs.encoder.Encode(frame)
+	cal := gs.calibrator()
+	if cap(gs.pixels) < len(frame.Pixels) {
+		gs.pixels = make([]color.RGBA64, len(frame.Pixels))
+	}
+	gs.pixels = gs.pixels[:len(frame.Pixels)]
+	for i, pixel := range frame.Pixels {
+		values := cal.values(pixel, false)
+		// expand the calibrated values back to 16 bit
+		gs.pixels[i] = color.RGBA64{
+			R: uint16(values[channelRed]) * 0x101,
+			G: uint16(values[channelGreen]) * 0x101,
+			B: uint16(values[channelBlue]) * 0x101,
+			A: pixel.A,
+		}
+	}
+
+	err := gs.encoder.Encode(scanner.Frame{Time: frame.Time, Pixels: gs.pixels})
 	if err != nil {
 		fmt.Println("gob streamer:", err)
 	}
@@ -0,0 +1,148 @@
+package streamer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errorLogInterval is the time during which repetitions of an error are not logged again
+const errorLogInterval = 10 * time.Second
+
+// failingDuration is the time after an error during which an output is considered to be failing.
+// UDP reports an unreachable port only on the write after the one that caused it, so outputs without a receiver
+// alternate between successful and failed writes.
+const failingDuration = 2 * time.Second
+
+// OutputHealth describes whether the packets of an output reach their destination.
+// UDP can't detect whether a device receives the packets, but the operating system reports when the host or port
+// is unreachable, which marks the output as failing.
+type OutputHealth struct {
+	Enabled       bool
+	Connected     bool // the last packet has been sent successfully
+	Failing       bool // the connection or a packet failed recently
+	LastError     error
+	LastErrorTime time.Time
+	PacketsSent   uint64
+	PacketsFailed uint64
+}
+
+// healthWriter records the OutputHealth of the writes to its destination
+type healthWriter struct {
+	destination io.Writer
+	health      *outputHealth
+}
+
+func (w healthWriter) Write(data []byte) (int, error) {
+	n, err := w.destination.Write(data)
+	w.health.record(err)
+	return n, err
+}
+
+// outputHealth is the OutputHealth of an output that can be updated concurrently
+type outputHealth struct {
+	health OutputHealth
+	mutex  sync.Mutex
+}
+
+// record updates the health after a packet has been written or a connection has been attempted
+func (h *outputHealth) record(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if errors.Is(err, errWaitingForRetry) {
+		// the packet has been dropped but the error that caused it has already been recorded
+		h.health.Connected = false
+		h.health.PacketsFailed++
+		return
+	}
+	if err != nil {
+		h.health.Connected = false
+		h.health.Failing = true
+		h.health.LastError = err
+		h.health.LastErrorTime = time.Now()
+		h.health.PacketsFailed++
+		return
+	}
+	h.health.Connected = true
+	h.health.PacketsSent++
+}
+
+// fail marks the output as failing without counting a packet
+func (h *outputHealth) fail(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.health.Connected = false
+	h.health.LastError = err
+	h.health.LastErrorTime = time.Now()
+}
+
+func (h *outputHealth) get() OutputHealth {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	health := h.health
+	health.Failing = health.LastError != nil && time.Since(health.LastErrorTime) < failingDuration
+	return health
+}
+
+// streamingErrors is used by all streamers to report errors of their destination
+var streamingErrors errorLog
+
+// errorLog logs errors but suppresses repetitions of the same error for the errorLogInterval.
+// A streamer usually fails on every frame until the problem is solved which would flood the log otherwise.
+type errorLog struct {
+	entries map[string]*errorLogEntry
+	mutex   sync.Mutex
+}
+
+type errorLogEntry struct {
+	logged     time.Time // the time at which the error has been logged the last time
+	suppressed int       // the number of repetitions since then
+}
+
+func (l *errorLog) log(err error) {
+	if errors.Is(err, errWaitingForRetry) {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.entries == nil {
+		l.entries = make(map[string]*errorLogEntry)
+	}
+
+	message := errorLogKey(err)
+	now := time.Now()
+	entry, ok := l.entries[message]
+	if ok && now.Sub(entry.logged) < errorLogInterval {
+		entry.suppressed++
+		return
+	}
+	if ok && entry.suppressed > 0 {
+		logrus.Errorf("streaming error: %v (repeated %d times)", err, entry.suppressed)
+	} else {
+		logrus.Errorf("streaming error: %v", err)
+	}
+
+	// forget errors that didn't occur for a while
+	for key, other := range l.entries {
+		if now.Sub(other.logged) > errorLogInterval {
+			delete(l.entries, key)
+		}
+	}
+	l.entries[message] = &errorLogEntry{logged: now}
+}
+
+// errorLogKey returns the message of the error without the local address.
+// A socket that is recreated after an error gets a new local port which would make every error look different.
+func errorLogKey(err error) string {
+	message := err.Error()
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Source != nil {
+		message = strings.Replace(message, opErr.Source.String(), "", 1)
+	}
+	return message
+}
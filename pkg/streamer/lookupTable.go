@@ -0,0 +1,39 @@
+package streamer
+
+import "math"
+
+// A lookupTable maps the 16 bit color channels of a scanner.Frame to the 8 bit values that are sent to a device.
+// Precomputing the values prevents expensive calculations for every channel of every pixel.
+type lookupTable [0x10000]byte
+
+// newGammaTable returns a lookupTable that applies the gamma correction while mapping from 0xffff to 0xff
+func newGammaTable(gamma float64) *lookupTable {
+	table := new(lookupTable)
+	for i := range table {
+		table[i] = byte(math.Pow(float64(i)/0xffff, gamma) * 0xff)
+	}
+	return table
+}
+
+// newCurveTable returns a lookupTable that follows the curve while mapping from 0xffff to 0xff.
+// The curve contains evenly spaced values between 0 and 1 that are interpolated linearly.
+func newCurveTable(curve []float64) *lookupTable {
+	table := new(lookupTable)
+	if len(curve) == 1 {
+		for i := range table {
+			table[i] = byte(math.Min(1, math.Max(0, curve[0])) * 0xff)
+		}
+		return table
+	}
+	for i := range table {
+		position := float64(i) / 0xffff * float64(len(curve)-1)
+		index := int(position)
+		if index >= len(curve)-1 {
+			index = len(curve) - 2
+		}
+		fraction := position - float64(index)
+		value := curve[index] + (curve[index+1]-curve[index])*fraction
+		table[i] = byte(math.Min(1, math.Max(0, value)) * 0xff)
+	}
+	return table
+}
@@ -0,0 +1,83 @@
+package streamer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// OPCPort is the TCP port that is usually used by Open Pixel Control servers
+const OPCPort = 7890
+
+const (
+	opcHeaderLength     = 4
+	opcSetPixelColors   = 0
+	opcMaxDataLength    = 0xffff
+	opcBroadcastChannel = 0
+)
+
+// OPCStreamer sends frames as "set pixel colors" messages of the Open Pixel Control protocol.
+// OPC is used over TCP so the destination should usually be a TCPWriter.
+type OPCStreamer struct {
+	destination io.Writer
+	Channel     byte // the channel that the messages are sent to, 0 addresses all channels
+	calibrated
+	buffer []byte // reused between frames
+	mutex  sync.Mutex
+}
+
+// NewOPC creates a new OPC streamer that writes to the destination.
+// OPC servers like the fadecandy server perform their own color correction so none is applied here.
+func NewOPC(dst io.Writer) *OPCStreamer {
+	return &OPCStreamer{
+		destination: dst,
+		Channel:     opcBroadcastChannel,
+		calibrated:  calibrated{defaultGamma: ProtocolOPC.DefaultGamma()},
+	}
+}
+
+func (s *OPCStreamer) SetDestination(writer io.Writer) {
+	s.mutex.Lock()
+	s.destination = writer
+	s.mutex.Unlock()
+}
+
+// maxPixels returns the number of pixels that fit into a single message with the current calibration.
+// The length of the data is a 16 bit value so larger frames can't be sent.
+func (s *OPCStreamer) maxPixels() int {
+	return opcMaxDataLength / s.calibrator().channels()
+}
+
+func (s *OPCStreamer) Stream(frame scanner.Frame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.destination == nil {
+		return
+	}
+
+	cal := s.calibrator()
+	pixels := frame.Pixels
+	if len(pixels) > s.maxPixels() {
+		// sending only a part of the pixels would leave the rest of the LEDs in an outdated state
+		streamingErrors.log(fmt.Errorf("%d pixels don't fit into a single OPC message of at most %d pixels", len(pixels), s.maxPixels()))
+		return
+	}
+
+	size := cal.channels() * len(pixels)
+	packet := resizeBuffer(s.buffer, opcHeaderLength+size)
+	s.buffer = packet
+	packet[0] = s.Channel
+	packet[1] = opcSetPixelColors
+	packet[2] = byte(size >> 8)
+	packet[3] = byte(size)
+	data := packet[opcHeaderLength:]
+	for _, pixel := range pixels {
+		data = data[cal.write(data, pixel):]
+	}
+	_, err := s.destination.Write(packet)
+	if err != nil {
+		streamingErrors.log(err)
+	}
+}
@@ -0,0 +1,29 @@
+package streamer
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+func TestOPCFrameSizeLimit(t *testing.T) {
+	config := DefaultOutputConfig()
+	config.Protocol = ProtocolOPC
+	config.Mapping = *scanner.NewLinearMapping(opcMaxDataLength/3 + 1)
+	if _, err := config.NewStreamer(); err == nil {
+		t.Errorf("a mapping with %d pixels has been accepted", config.Mapping.Pixels())
+	}
+
+	var buffer bytes.Buffer
+	str := NewOPC(&buffer)
+	str.Stream(scanner.Frame{Pixels: make([]color.RGBA64, str.maxPixels()+1)})
+	if buffer.Len() != 0 {
+		t.Errorf("%d bytes of a frame that is too large have been sent", buffer.Len())
+	}
+	str.Stream(scanner.Frame{Pixels: make([]color.RGBA64, str.maxPixels())})
+	if buffer.Len() != opcHeaderLength+opcMaxDataLength {
+		t.Errorf("the largest frame has %d bytes instead of %d", buffer.Len(), opcHeaderLength+opcMaxDataLength)
+	}
+}
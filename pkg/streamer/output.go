@@ -0,0 +1,346 @@
+package streamer
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// OutputConfig describes a single device that frames are streamed to.
+// Every output has its own mapping, so multiple devices can show different regions of a scene or the same region
+// with a different number of pixels.
+type OutputConfig struct {
+	Name         string   `json:"name"`
+	Enabled      bool     `json:"enabled"`
+	Protocol     Protocol `json:"protocol"`
+	Address      string   `json:"address"`      // the host of network protocols
+	Port         int      `json:"port"`         // 0 uses the default port of the protocol
+	SerialDevice string   `json:"serialDevice"` // the device of serial protocols
+	BaudRate     int      `json:"baudRate"`
+	// Timeout is the number of seconds after which a WLED device returns to its normal mode.
+	// A value of 0 or above 255 keeps the last frame forever.
+	Timeout    int `json:"timeout"`
+	OPCChannel int `json:"opcChannel"`
+	// E131Universe is the universe of the first pixel between 1 and 63999, 0 uses universe 1
+	E131Universe int `json:"e131Universe"`
+	// E131StartChannel is the channel of the first pixel in its universe beginning at 1, 0 uses channel 1
+	E131StartChannel int `json:"e131StartChannel"`
+	// E131Priority is the priority of the data between 1 and 200, 0 uses the default priority of 100
+	E131Priority   int    `json:"e131Priority"`
+	E131SourceName string `json:"e131SourceName"` // an empty name uses "Firefly"
+	// E131Multicast sends every universe to its multicast group instead of the address
+	E131Multicast bool `json:"e131Multicast"`
+	// ArtNetNet, ArtNetSubNet and ArtNetUniverse make up the port address of the first pixel
+	ArtNetNet         int  `json:"artNetNet"`      // between 0 and 127
+	ArtNetSubNet      int  `json:"artNetSubNet"`   // between 0 and 15
+	ArtNetUniverse    int  `json:"artNetUniverse"` // between 0 and 15
+	ArtNetDisableSync bool `json:"artNetDisableSync"`
+	// PixelsPerUniverse limits the number of pixels in an E1.31 or Art-Net universe, 0 fills the universes
+	PixelsPerUniverse int             `json:"pixelsPerUniverse"`
+	Mapping           scanner.Mapping `json:"mapping"`
+	Calibration       Calibration     `json:"calibration"`
+	Power             PowerModel      `json:"power"`
+	// MotionBlurSamples is the number of samples that are averaged for every frame, 0 or 1 disables motion blur
+	MotionBlurSamples int `json:"motionBlurSamples"`
+}
+
+// DefaultOutputConfig returns the configuration of a disabled WLED output with 30 pixels
+func DefaultOutputConfig() OutputConfig {
+	return OutputConfig{
+		Name:     "LED Strip",
+		Protocol: ProtocolWLED,
+		Address:  "127.0.0.1",
+		BaudRate: 115200,
+		Timeout:  2,
+
+		E131Universe:     1,
+		E131StartChannel: 1,
+		E131Priority:     100,
+		E131SourceName:   "Firefly",
+
+		Mapping:     *scanner.NewLinearMapping(30),
+		Calibration: DefaultCalibration(),
+		Power:       DefaultPowerModel(),
+
+		MotionBlurSamples: 1,
+	}
+}
+
+// Output scans a scene with the mapping of its config and streams the frames to a device
+type Output struct {
+	Config    OutputConfig
+	Scanner   scanner.Scanner
+	Streamer  DestinationStreamer
+	Limiter   *PowerLimiter    // limits the current of the frames that are streamed
+	lastFrame scanner.Frame    // the last frame without the limit applied
+	frames    [2]scanner.Frame // the output alternates between these to reuse their memory
+	next      int              // the index of the frame that will be scanned next
+	device    io.Closer        // the serial device or connection that is used by the streamer
+	retry     retryBackoff     // decides when a failed connection is attempted again
+	health    outputHealth
+	recorder  Streamer   // receives the same frames as the device
+	recording sync.Mutex // protects recorder and is held while a frame is recorded
+	mutex     sync.Mutex // protects lastFrame
+}
+
+// NewOutput creates an output for the config that scans the scene.
+// The output doesn't stream anything until Connect has been called.
+func NewOutput(scene *project.Scene, config OutputConfig) (*Output, error) {
+	if config.Protocol == "" {
+		config.Protocol = ProtocolWLED
+	}
+	str, err := config.NewStreamer()
+	if err != nil {
+		return nil, err
+	}
+
+	sca := scanner.New(scene, 1)
+	sca.SetMapping(config.Mapping)
+	output := &Output{
+		Config:   config,
+		Scanner:  sca,
+		Streamer: str,
+		Limiter:  NewPowerLimiter(config.PowerModel()),
+	}
+	if s, ok := str.(*E131Streamer); ok {
+		s.recordMulticastHealth(&output.health)
+	}
+	return output, nil
+}
+
+// PowerModel returns the power model of the config that derives the duty cycle from the calibration of the output
+// unless the model specifies a gamma
+func (c OutputConfig) PowerModel() PowerModel {
+	protocol := c.Protocol
+	if protocol == "" {
+		protocol = ProtocolWLED
+	}
+	return c.Power.Calibrated(c.Calibration, protocol.DefaultGamma())
+}
+
+// NewStreamer creates a streamer for the protocol of the config with its calibration and protocol specific settings.
+// The streamer has no destination yet.
+func (c OutputConfig) NewStreamer() (DestinationStreamer, error) {
+	protocol := c.Protocol
+	if protocol == "" {
+		protocol = ProtocolWLED
+	}
+	str, err := New(protocol, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = str.SetCalibration(c.Calibration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calibration: %w", err)
+	}
+	switch s := str.(type) {
+	case *WLEDStreamer:
+		if c.Timeout > 0 && c.Timeout <= 255 {
+			s.Timeout = byte(c.Timeout)
+		} else {
+			s.Timeout = 255
+		}
+	case *OPCStreamer:
+		if pixels := c.Mapping.Pixels(); pixels > s.maxPixels() {
+			return nil, fmt.Errorf("the mapping has %d pixels but a single OPC message can only contain %d", pixels, s.maxPixels())
+		}
+		s.Channel = byte(c.OPCChannel)
+	case *TPM2Streamer:
+		if pixels := c.Mapping.Pixels(); pixels > s.maxPixels() {
+			return nil, fmt.Errorf("the mapping has %d pixels but a single TPM2 frame can only contain %d", pixels, s.maxPixels())
+		}
+	case *E131Streamer:
+		if c.E131Universe < 0 || c.E131Universe > E131MaxUniverse {
+			return nil, fmt.Errorf("the universe %d needs to be between 1 and %d", c.E131Universe, E131MaxUniverse)
+		}
+		if c.E131Universe > 0 {
+			s.StartUniverse = uint16(c.E131Universe)
+		}
+		if c.E131StartChannel < 0 || c.E131StartChannel > e131MaxChannels {
+			return nil, fmt.Errorf("the start channel %d needs to be between 1 and %d", c.E131StartChannel, e131MaxChannels)
+		}
+		if c.E131StartChannel > 0 {
+			s.StartChannel = c.E131StartChannel
+		}
+		if c.E131Priority < 0 || c.E131Priority > 200 {
+			return nil, fmt.Errorf("the priority %d needs to be between 0 and 200", c.E131Priority)
+		}
+		if c.E131Priority > 0 {
+			s.Priority = byte(c.E131Priority)
+		}
+		if c.E131SourceName != "" {
+			s.SourceName = c.E131SourceName
+		}
+		if c.PixelsPerUniverse < 0 {
+			return nil, fmt.Errorf("invalid number of pixels per universe %d", c.PixelsPerUniverse)
+		}
+		if c.PixelsPerUniverse > 0 {
+			s.PixelsPerUniverse = c.PixelsPerUniverse
+		}
+		s.SetMulticast(c.E131Multicast)
+	case *ArtNetStreamer:
+		if c.ArtNetNet < 0 || c.ArtNetNet > 127 {
+			return nil, fmt.Errorf("the net %d needs to be between 0 and 127", c.ArtNetNet)
+		}
+		if c.ArtNetSubNet < 0 || c.ArtNetSubNet > 15 {
+			return nil, fmt.Errorf("the sub-net %d needs to be between 0 and 15", c.ArtNetSubNet)
+		}
+		if c.ArtNetUniverse < 0 || c.ArtNetUniverse > 15 {
+			return nil, fmt.Errorf("the universe %d needs to be between 0 and 15", c.ArtNetUniverse)
+		}
+		if c.PixelsPerUniverse < 0 {
+			return nil, fmt.Errorf("invalid number of pixels per universe %d", c.PixelsPerUniverse)
+		}
+		s.Net = byte(c.ArtNetNet)
+		s.SubNet = byte(c.ArtNetSubNet)
+		s.Universe = byte(c.ArtNetUniverse)
+		s.DisableSync = c.ArtNetDisableSync
+		if c.PixelsPerUniverse > 0 {
+			s.PixelsPerUniverse = c.PixelsPerUniverse
+		}
+	}
+	return str, nil
+}
+
+// Connect opens the serial device or network connection of the output if it is enabled.
+// If it fails the output tries again while it is being updated.
+func (o *Output) Connect() error {
+	if !o.Config.Enabled {
+		return nil
+	}
+	writer, device, err := o.Config.OpenDestination()
+	if err != nil {
+		o.health.fail(err)
+		o.retry.failed()
+		return err
+	}
+	o.retry.succeeded()
+	o.Streamer.SetDestination(healthWriter{destination: writer, health: &o.health})
+	o.device = device
+	return nil
+}
+
+// Health returns whether the packets of the output reach their destination
+func (o *Output) Health() OutputHealth {
+	health := o.health.get()
+	health.Enabled = o.Config.Enabled
+	return health
+}
+
+// OpenDestination opens the serial device or network connection of the config.
+// The returned closer needs to be closed after the writer isn't used anymore.
+func (c OutputConfig) OpenDestination() (io.Writer, io.Closer, error) {
+	protocol := c.Protocol
+	if protocol == "" {
+		protocol = ProtocolWLED
+	}
+	if protocol.IsSerial() {
+		if c.SerialDevice == "" {
+			return nil, nil, errors.New("no serial device has been configured")
+		}
+		serial, err := OpenSerial(c.SerialDevice, c.BaudRate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return serial, serial, nil
+	}
+
+	if protocol == ProtocolE131 && c.E131Multicast {
+		// the streamer connects to the multicast groups itself
+		return io.Discard, noDevice{}, nil
+	}
+
+	if c.Address == "" {
+		return nil, nil, errors.New("no address has been configured")
+	}
+	port := c.Port
+	if port == 0 {
+		port = protocol.DefaultPort()
+	}
+	address := net.JoinHostPort(c.Address, strconv.Itoa(port))
+	if protocol.IsTCP() {
+		tcpWriter := NewTCPWriter(address)
+		return tcpWriter, tcpWriter, nil
+	}
+	udpWriter, err := NewUDPWriter(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return udpWriter, udpWriter, nil
+}
+
+// noDevice is the closer of destinations that don't have anything to close
+type noDevice struct{}
+
+func (noDevice) Close() error { return nil }
+
+// Update scans the frame at the time and streams it to the device
+func (o *Output) Update(time float64) {
+	if o.Config.Enabled && o.device == nil && o.retry.ready() {
+		err := o.Connect()
+		if err != nil {
+			streamingErrors.log(fmt.Errorf("output %q: %w", o.Config.Name, err))
+		}
+	}
+
+	// The frame is scanned into the buffer that is not used by lastFrame.
+	// That way LastFrameInto can copy lastFrame while the next frame is being scanned.
+	frame := &o.frames[o.next]
+	o.Scanner.ScanInto(time, frame)
+	o.mutex.Lock()
+	o.lastFrame = *frame
+	o.mutex.Unlock()
+	o.next = 1 - o.next
+	limited := o.Limiter.Limit(*frame)
+	o.Streamer.Stream(limited)
+	o.recording.Lock()
+	if o.recorder != nil {
+		o.recorder.Stream(limited)
+	}
+	o.recording.Unlock()
+}
+
+// Record passes every following frame to the recorder exactly as it is sent to the device.
+// A nil recorder stops recording. Record waits until a frame that is being recorded at the moment is complete,
+// so the previous recorder can be closed afterwards.
+func (o *Output) Record(recorder Streamer) {
+	o.recording.Lock()
+	o.recorder = recorder
+	o.recording.Unlock()
+}
+
+// LastFrameInto copies the last frame that has been scanned without the limit applied into the frame.
+// The pixels of the frame are reused if they have enough capacity.
+func (o *Output) LastFrameInto(frame *scanner.Frame) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	frame.Time = o.lastFrame.Time
+	if cap(frame.Pixels) >= len(o.lastFrame.Pixels) {
+		frame.Pixels = frame.Pixels[:len(o.lastFrame.Pixels)]
+	} else {
+		frame.Pixels = make([]color.RGBA64, len(o.lastFrame.Pixels))
+	}
+	copy(frame.Pixels, o.lastFrame.Pixels)
+}
+
+// Close stops streaming and closes the device of the output
+func (o *Output) Close() error {
+	o.Streamer.SetDestination(nil)
+	if s, ok := o.Streamer.(*E131Streamer); ok {
+		// closes the connections to the multicast groups
+		s.SetMulticast(false)
+	}
+	if o.device == nil {
+		return nil
+	}
+	err := o.device.Close()
+	o.device = nil
+	return err
+}
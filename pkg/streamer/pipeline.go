@@ -1,45 +1,249 @@
 package streamer
 
 import (
-	"github.com/omniskop/firefly/pkg/scanner"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
-// Pipeline contains a scanner and a streamer that operate asynchronously.
-// Pipe a time into the Update channel to start a scan and a subsequent stream.
+// DefaultFrameRate is the number of frames per second that a Pipeline renders if no other rate has been set
+const DefaultFrameRate = 60
+
+// maxBacktrack is the largest step in seconds by which the time of a playing pipeline is allowed to go backwards.
+// Smaller steps are caused by the jitter of the time source and are ignored to prevent the outputs from flickering.
+const maxBacktrack = 0.1
+
+// Pipeline renders frames on all of its outputs at a fixed frame rate.
+// The time of the frames is extrapolated from the last time that has been passed to Sync, so the source of the time
+// doesn't need to be as precise as the frame rate. Every output runs concurrently and if an output is still busy
+// with a previous frame when the next one is due, the previous frame is dropped instead of being queued.
 type Pipeline struct {
-	Scanner   scanner.Scanner
-	Streamers []Streamer
-	LastFrame scanner.Frame
-	Update    chan float64
+	stats   pipelineCounters // first field to keep the counters aligned for atomic access on 32 bit platforms
+	outputs []*outputWorker
+	mutex   sync.Mutex // protects the outputs
+
+	clock    clock
+	interval chan time.Duration // changes the interval of the ticker
+	stop     chan struct{}
+}
+
+// PipelineStats contains counters about the frames of a Pipeline
+type PipelineStats struct {
+	Rendered uint64 // frames that have been streamed by an output
+	Dropped  uint64 // frames that have been replaced by a newer one before an output could start rendering them
+	Late     uint64 // frames that have been started after the next frame was already due
+}
+
+type pipelineCounters struct {
+	rendered, dropped, late uint64
+}
+
+// clock extrapolates the time of the frames
+type clock struct {
+	mutex    sync.Mutex
+	time     float64   // the time of the last sync
+	rate     float64   // the speed at which the time advances, 0 while paused
+	synced   time.Time // the moment of the last sync
+	dirty    bool      // true if a frame needs to be rendered even though the clock is paused
+	rendered float64   // the time of the last rendered frame
+}
+
+// frameJob is a frame that an output should render
+type frameJob struct {
+	time float64   // the time of the frame in the project
+	next time.Time // the moment at which the following frame will be due
+}
+
+// outputWorker renders the frames of a single output in its own goroutine
+type outputWorker struct {
+	output *Output
+	jobs   chan frameJob // holds at most one pending frame
+	done   chan struct{} // closed after the worker has stopped
 }
 
-// NewPipeline creates a new Pipeline
-func NewPipeline(sca scanner.Scanner, str Streamer) *Pipeline {
+// NewPipeline creates a new Pipeline with the outputs that renders at the DefaultFrameRate
+func NewPipeline(outputs ...*Output) *Pipeline {
 	sp := &Pipeline{
-		Scanner:   sca,
-		Streamers: []Streamer{str},
-		LastFrame: scanner.Frame{},
-		Update:    make(chan float64),
+		interval: make(chan time.Duration),
+		stop:     make(chan struct{}),
 	}
-	go sp.routine()
+	sp.SetOutputs(outputs)
+	go sp.routine(frameInterval(DefaultFrameRate))
 	return sp
 }
 
-func (sp *Pipeline) AddStreamer(streamer Streamer) {
-	sp.Streamers = append(sp.Streamers, streamer)
+func frameInterval(fps float64) time.Duration {
+	if fps <= 0 {
+		fps = DefaultFrameRate
+	}
+	return time.Duration(float64(time.Second) / fps)
+}
+
+// SetFrameRate changes the number of frames per second. A rate of 0 or less uses the DefaultFrameRate.
+func (sp *Pipeline) SetFrameRate(fps float64) {
+	select {
+	case sp.interval <- frameInterval(fps):
+	case <-sp.stop:
+	}
+}
+
+// Sync tells the pipeline the current time and the rate at which it advances, usually the playback rate of the audio.
+// A rate of 0 means that the time is paused, the next frame will show the time and then no more frames are rendered
+// until the time changes. Sync never blocks.
+func (sp *Pipeline) Sync(t float64, rate float64) {
+	sp.clock.mutex.Lock()
+	sp.clock.time = t
+	sp.clock.rate = rate
+	sp.clock.synced = time.Now()
+	sp.clock.dirty = true
+	sp.clock.mutex.Unlock()
+}
+
+// next returns the time of the frame that is due at the moment and false if no frame needs to be rendered
+func (c *clock) next(at time.Time) (float64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.rate == 0 {
+		if !c.dirty {
+			return 0, false
+		}
+		c.dirty = false
+		c.rendered = c.time
+		return c.time, true
+	}
+
+	t := c.time + at.Sub(c.synced).Seconds()*c.rate
+	if t < c.rendered && c.rendered-t < maxBacktrack && !c.dirty {
+		t = c.rendered
+	}
+	c.dirty = false
+	c.rendered = t
+	return t, true
 }
 
-// Stop the pipeline. After calling Stop the Update channel will be closed.
+// Outputs returns the outputs of the pipeline
+func (sp *Pipeline) Outputs() []*Output {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+	outputs := make([]*Output, len(sp.outputs))
+	for i, worker := range sp.outputs {
+		outputs[i] = worker.output
+	}
+	return outputs
+}
+
+// SetOutputs replaces the outputs of the pipeline.
+// The previous outputs are closed in the background after they finished their current frame, because closing a
+// connection that is still being established can take a long time.
+func (sp *Pipeline) SetOutputs(outputs []*Output) {
+	go stopWorkers(sp.replaceOutputs(outputs))
+}
+
+// replaceOutputs starts workers for the outputs and returns the previous workers which are still running
+func (sp *Pipeline) replaceOutputs(outputs []*Output) []*outputWorker {
+	workers := make([]*outputWorker, len(outputs))
+	for i, output := range outputs {
+		workers[i] = &outputWorker{
+			output: output,
+			jobs:   make(chan frameJob, 1),
+			done:   make(chan struct{}),
+		}
+		go workers[i].routine(&sp.stats)
+	}
+
+	sp.mutex.Lock()
+	previous := sp.outputs
+	sp.outputs = workers
+	sp.mutex.Unlock()
+
+	// the new outputs need to show the current time even if the clock is paused
+	sp.clock.mutex.Lock()
+	sp.clock.dirty = true
+	sp.clock.mutex.Unlock()
+
+	return previous
+}
+
+// Stats returns the counters of all frames since the pipeline has been created
+func (sp *Pipeline) Stats() PipelineStats {
+	return PipelineStats{
+		Rendered: atomic.LoadUint64(&sp.stats.rendered),
+		Dropped:  atomic.LoadUint64(&sp.stats.dropped),
+		Late:     atomic.LoadUint64(&sp.stats.late),
+	}
+}
+
+// Stop the pipeline and close all of its outputs. Unlike SetOutputs it waits until the outputs have been closed.
 func (sp *Pipeline) Stop() {
-	close(sp.Update)
+	close(sp.stop)
+	stopWorkers(sp.replaceOutputs(nil))
 }
 
-// routine listens on the Update channel to start the scanner and streamer
-func (sp *Pipeline) routine() {
-	for time := range sp.Update {
-		sp.LastFrame = sp.Scanner.Scan(time)
-		for _, streamer := range sp.Streamers {
-			streamer.Stream(sp.LastFrame)
+// routine renders a frame on every tick of the clock
+func (sp *Pipeline) routine(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sp.stop:
+			return
+		case interval = <-sp.interval:
+			ticker.Reset(interval)
+		case <-ticker.C:
+			at := time.Now()
+			t, ok := sp.clock.next(at)
+			if !ok {
+				continue
+			}
+			job := frameJob{time: t, next: at.Add(interval)}
+			sp.mutex.Lock()
+			for _, worker := range sp.outputs {
+				worker.schedule(job, &sp.stats)
+			}
+			sp.mutex.Unlock()
+		}
+	}
+}
+
+// schedule passes the job to the worker. A job that the worker didn't start yet is replaced.
+func (w *outputWorker) schedule(job frameJob, stats *pipelineCounters) {
+	for {
+		select {
+		case w.jobs <- job:
+			return
+		default:
+		}
+		select {
+		case <-w.jobs:
+			atomic.AddUint64(&stats.dropped, 1)
+		default:
+		}
+	}
+}
+
+func (w *outputWorker) routine(stats *pipelineCounters) {
+	defer close(w.done)
+	for job := range w.jobs {
+		if time.Now().After(job.next) {
+			atomic.AddUint64(&stats.late, 1)
+		}
+		w.output.Update(job.time)
+		atomic.AddUint64(&stats.rendered, 1)
+	}
+}
+
+// stopWorkers waits until the workers finished their current frame and closes their outputs
+func stopWorkers(workers []*outputWorker) {
+	for _, worker := range workers {
+		close(worker.jobs)
+	}
+	for _, worker := range workers {
+		<-worker.done
+		err := worker.output.Close()
+		if err != nil {
+			logrus.Errorf("output %q could not be closed: %v", worker.output.Config.Name, err)
 		}
 	}
 }
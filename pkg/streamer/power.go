@@ -0,0 +1,243 @@
+package streamer
+
+import (
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/project"
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// PowerModel describes how much current the LEDs of an output draw and how much the power supply can deliver.
+// All currents are in milliampere.
+type PowerModel struct {
+	ChannelCurrent float64 `json:"channelCurrent"` // the current of a single channel at full brightness, usually 20mA
+	IdleCurrent    float64 `json:"idleCurrent"`    // the current of a pixel when it is off, usually about 1mA
+	Limit          float64 `json:"limit"`          // the current the power supply can deliver, 0 disables the limit
+	// Gamma describes how the values of a frame relate to the duty cycle of the LEDs.
+	// It should match the gamma that the device applies. A value of 0 derives the duty cycle from the calibrated
+	// values that are sent to the device if the model has been Calibrated and is treated as 1 otherwise.
+	Gamma float64 `json:"gamma"`
+
+	calibration *calibrator // converts the pixels into the values that the device receives
+}
+
+// DefaultPowerModel returns a PowerModel for WS2812 LEDs that is not limited
+func DefaultPowerModel() PowerModel {
+	return PowerModel{ChannelCurrent: 20, IdleCurrent: 1}
+}
+
+// Calibrated returns a copy of the model that derives the duty cycle from the values that the calibration produces
+// if the model doesn't have a Gamma. Channels without a gamma use the defaultGamma, which should be the gamma that
+// the streamer applies. An invalid calibration is ignored.
+func (m PowerModel) Calibrated(calibration Calibration, defaultGamma float64) PowerModel {
+	m.calibration, _ = newCalibrator(calibration, defaultGamma)
+	return m
+}
+
+// calibrated returns true if the duty cycle is derived from the calibration
+func (m PowerModel) calibrated() bool {
+	return m.Gamma <= 0 && m.calibration != nil
+}
+
+// Enabled returns true if the model limits the current
+func (m PowerModel) Enabled() bool {
+	return m.Limit > 0 && m.ChannelCurrent > 0
+}
+
+func (m PowerModel) gamma() float64 {
+	if m.Gamma <= 0 {
+		return 1
+	}
+	return m.Gamma
+}
+
+// Current returns the estimated current that the output draws while showing the frame
+func (m PowerModel) Current(frame scanner.Frame) float64 {
+	return m.IdleCurrent*float64(len(frame.Pixels)) + m.ChannelCurrent*m.dutySum(frame.Pixels)
+}
+
+// dutySum returns the sum of the duty cycles of all channels of the pixels
+func (m PowerModel) dutySum(pixels []color.RGBA64) float64 {
+	if m.calibrated() {
+		return m.calibratedDutySum(pixels, 0x10000)
+	}
+	gamma := m.gamma()
+	var sum float64
+	for _, pixel := range pixels {
+		if gamma == 1 {
+			sum += (float64(pixel.R) + float64(pixel.G) + float64(pixel.B)) / 0xffff
+		} else {
+			sum += math.Pow(float64(pixel.R)/0xffff, gamma) +
+				math.Pow(float64(pixel.G)/0xffff, gamma) +
+				math.Pow(float64(pixel.B)/0xffff, gamma)
+		}
+	}
+	return sum
+}
+
+// calibratedDutySum returns the sum of the duty cycles of all channels that the device receives after the pixels
+// have been scaled by the factor, which is a fixed point number with 16 bits like in PowerLimiter.Limit.
+func (m PowerModel) calibratedDutySum(pixels []color.RGBA64, factor uint32) float64 {
+	var sum int
+	for _, pixel := range pixels {
+		if factor < 0x10000 {
+			pixel = scalePixel(pixel, factor)
+		}
+		values := m.calibration.values(pixel, m.calibration.white)
+		sum += int(values[0]) + int(values[1]) + int(values[2]) + int(values[3])
+	}
+	return float64(sum) / 0xff
+}
+
+// Scale returns the factor by which the values of the frame need to be scaled to stay within the limit.
+// A factor of 1 means that the frame doesn't exceed the limit.
+func (m PowerModel) Scale(frame scanner.Frame) float64 {
+	if !m.Enabled() {
+		return 1
+	}
+	duty := m.dutySum(frame.Pixels)
+	if duty == 0 {
+		return 1
+	}
+	available := m.Limit - m.IdleCurrent*float64(len(frame.Pixels))
+	if available <= 0 {
+		return 0
+	}
+	ratio := available / (m.ChannelCurrent * duty)
+	if ratio >= 1 {
+		return 1
+	}
+	if m.calibrated() {
+		return m.calibratedScale(frame.Pixels, available/m.ChannelCurrent)
+	}
+	// the current is proportional to the duty cycle which is the value raised to the gamma
+	return math.Pow(ratio, 1/m.gamma())
+}
+
+// calibratedScale returns the largest factor for which the calibrated duty cycles of the pixels don't exceed the
+// available duty. The calibration doesn't need to follow a simple curve so the factor is searched.
+func (m PowerModel) calibratedScale(pixels []color.RGBA64, available float64) float64 {
+	low, high := uint32(0), uint32(0x10000)
+	for high-low > 1 {
+		middle := (low + high) / 2
+		if m.calibratedDutySum(pixels, middle) <= available {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return float64(low) / 0x10000
+}
+
+// PowerLimiter scales frames down when they would draw more current than the PowerModel allows.
+// This works like the automatic brightness limiter of WLED.
+type PowerLimiter struct {
+	model     PowerModel
+	lastScale float64
+	pixels    []color.RGBA64 // reused between frames
+	mutex     sync.Mutex
+}
+
+// NewPowerLimiter creates a new PowerLimiter with the model
+func NewPowerLimiter(model PowerModel) *PowerLimiter {
+	return &PowerLimiter{
+		model:     model,
+		lastScale: 1,
+	}
+}
+
+// SetModel changes the model that is used for all following frames
+func (l *PowerLimiter) SetModel(model PowerModel) {
+	l.mutex.Lock()
+	l.model = model
+	l.mutex.Unlock()
+}
+
+// Model returns the current model of the limiter
+func (l *PowerLimiter) Model() PowerModel {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.model
+}
+
+// LastScale returns the factor that was applied to the last frame
+func (l *PowerLimiter) LastScale() float64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.lastScale
+}
+
+// Limit returns the frame scaled down to stay within the limit of the model.
+// If the frame doesn't need to be changed it is returned as is. Otherwise the returned frame uses memory of the
+// limiter and is only valid until the next call.
+func (l *PowerLimiter) Limit(frame scanner.Frame) scanner.Frame {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	scale := l.model.Scale(frame)
+	l.lastScale = scale
+	if scale >= 1 {
+		return frame
+	}
+
+	if cap(l.pixels) < len(frame.Pixels) {
+		l.pixels = make([]color.RGBA64, len(frame.Pixels))
+	}
+	l.pixels = l.pixels[:len(frame.Pixels)]
+	factor := uint32(scale * 0x10000)
+	for i, pixel := range frame.Pixels {
+		l.pixels[i] = scalePixel(pixel, factor)
+	}
+	return scanner.Frame{Time: frame.Time, Pixels: l.pixels}
+}
+
+// scalePixel multiplies the color channels of the pixel with the factor, a fixed point number with 16 bits
+func scalePixel(pixel color.RGBA64, factor uint32) color.RGBA64 {
+	return color.RGBA64{
+		R: uint16(uint32(pixel.R) * factor >> 16),
+		G: uint16(uint32(pixel.G) * factor >> 16),
+		B: uint16(uint32(pixel.B) * factor >> 16),
+		A: pixel.A,
+	}
+}
+
+// PowerEstimate is the result of EstimateCurrent
+type PowerEstimate struct {
+	PeakCurrent float64 // the highest current in milliampere
+	PeakTime    float64 // the time at which the highest current occurs
+	Exceeded    float64 // the total time in seconds during which the limit of the model is exceeded
+}
+
+// EstimateCurrent scans the whole project with the mapping and returns the highest current that the output would
+// draw without a limiter. The project is sampled in steps of the interval in seconds.
+// If progress is not nil it is called after every step with the fraction of the project that has been scanned.
+// When it returns false the estimate stops and contains only the part of the project that has been scanned so far.
+func EstimateCurrent(proj *project.Project, mapping scanner.Mapping, model PowerModel, interval float64, progress func(done float64) bool) PowerEstimate {
+	if interval <= 0 {
+		interval = 1.0 / 60
+	}
+	scan := scanner.New(&proj.Scene, 1)
+	scan.SetMapping(mapping)
+
+	var estimate PowerEstimate
+	var frame scanner.Frame
+	steps := int(math.Ceil(proj.Duration / interval))
+	for i := 0; i <= steps; i++ {
+		time := math.Min(float64(i)*interval, proj.Duration)
+		scan.ScanInto(time, &frame)
+		current := model.Current(frame)
+		if current > estimate.PeakCurrent {
+			estimate.PeakCurrent = current
+			estimate.PeakTime = time
+		}
+		if model.Enabled() && current > model.Limit {
+			estimate.Exceeded += interval
+		}
+		if progress != nil && !progress(float64(i+1)/float64(steps+1)) {
+			break
+		}
+	}
+	return estimate
+}
@@ -0,0 +1,94 @@
+package streamer
+
+import (
+	"fmt"
+	"io"
+)
+
+// DestinationStreamer is a Streamer that writes to a destination that can be changed at any time
+type DestinationStreamer interface {
+	Streamer
+	SetDestination(writer io.Writer)
+	SetCalibration(calibration Calibration) error
+}
+
+// Protocol identifies one of the protocols that can be used to send frames to a device
+type Protocol string
+
+const (
+	ProtocolWLED   Protocol = "wled"   // the DRGB protocol of WLED
+	ProtocolDDP    Protocol = "ddp"    // the Distributed Display Protocol
+	ProtocolE131   Protocol = "e131"   // E1.31 (sACN)
+	ProtocolArtNet Protocol = "artnet" // Art-Net
+	ProtocolBasic  Protocol = "basic"  // the protocol of the BasicStreamer
+	ProtocolOPC    Protocol = "opc"    // Open Pixel Control over TCP
+
+	ProtocolAdalight Protocol = "adalight" // the Adalight format over a serial connection
+	ProtocolTPM2     Protocol = "tpm2"     // TPM2 over a serial connection
+)
+
+// Protocols contains all available protocols
+var Protocols = []Protocol{ProtocolWLED, ProtocolDDP, ProtocolE131, ProtocolArtNet, ProtocolBasic, ProtocolOPC, ProtocolAdalight, ProtocolTPM2}
+
+// IsSerial returns true if the protocol is used over a serial connection instead of the network
+func (p Protocol) IsSerial() bool {
+	return p == ProtocolAdalight || p == ProtocolTPM2
+}
+
+// IsTCP returns true if the protocol needs a TCP connection instead of UDP
+func (p Protocol) IsTCP() bool {
+	return p == ProtocolOPC
+}
+
+// DefaultPort returns the port that devices usually listen on for the protocol
+func (p Protocol) DefaultPort() int {
+	switch p {
+	case ProtocolWLED:
+		return 21324
+	case ProtocolDDP:
+		return DDPPort
+	case ProtocolE131:
+		return E131Port
+	case ProtocolArtNet:
+		return ArtNetPort
+	case ProtocolOPC:
+		return OPCPort
+	default:
+		return 20202
+	}
+}
+
+// DefaultGamma returns the gamma that the streamer of the protocol applies to channels whose calibration doesn't
+// specify one. Devices that perform their own gamma correction receive the values as they are.
+func (p Protocol) DefaultGamma() float64 {
+	switch p {
+	case ProtocolBasic, ProtocolAdalight, ProtocolTPM2:
+		return 2.2
+	default:
+		return 1
+	}
+}
+
+// New creates a new streamer for the protocol that writes to the destination
+func New(protocol Protocol, dst io.Writer) (DestinationStreamer, error) {
+	switch protocol {
+	case ProtocolWLED:
+		return NewWLED(dst), nil
+	case ProtocolDDP:
+		return NewDDP(dst), nil
+	case ProtocolE131:
+		return NewE131(dst), nil
+	case ProtocolArtNet:
+		return NewArtNet(dst), nil
+	case ProtocolBasic:
+		return NewBasic(dst), nil
+	case ProtocolOPC:
+		return NewOPC(dst), nil
+	case ProtocolAdalight:
+		return NewAdalight(dst), nil
+	case ProtocolTPM2:
+		return NewTPM2(dst), nil
+	default:
+		return nil, fmt.Errorf("unknown streaming protocol %q", protocol)
+	}
+}
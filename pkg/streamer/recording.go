@@ -0,0 +1,390 @@
+package streamer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+// A recording starts with the magic bytes and the version of the format followed by the frames.
+// Every frame is stored as:
+//
+//	uvarint  time since the previous frame in microseconds
+//	float64  the time of the frame in the project (little endian)
+//	uvarint  the number of pixels
+//	runs     pairs of uvarints with the number of pixels that didn't change since the previous frame and the number
+//	         of pixels that follow with their new value as four 16 bit channels (little endian)
+//
+// The runs continue until they cover all pixels of the frame.
+var recordingMagic = [4]byte{'F', 'F', 'R', 'C'}
+
+const recordingVersion = 1
+
+// maxRecordedPixels is the largest number of pixels in a frame that a RecordingReader accepts.
+// It prevents a corrupt recording from allocating an arbitrary amount of memory.
+const maxRecordedPixels = 1 << 20
+
+// ErrInvalidRecording is returned when a file is not a recording of a supported version
+var ErrInvalidRecording = errors.New("not a firefly recording")
+
+// Recorder is a Streamer that records every frame with its timestamp into a compact binary format.
+// The frames are stored without calibration so a recording can be replayed into any other streamer.
+// Pixels that didn't change since the previous frame are skipped which keeps recordings of slow animations small.
+type Recorder struct {
+	writer   *bufio.Writer
+	started  time.Time      // the time at which the first frame has been recorded
+	last     int64          // the offset of the previous frame in microseconds
+	previous []color.RGBA64 // the pixels of the previous frame
+	buffer   []byte         // reused between frames
+	err      error          // the first error that occurred, no more frames are recorded after it
+	mutex    sync.Mutex
+}
+
+// NewRecorder creates a new Recorder that writes the recording to the destination.
+// Flush needs to be called after the last frame.
+func NewRecorder(dst io.Writer) *Recorder {
+	r := &Recorder{writer: bufio.NewWriter(dst)}
+	r.writer.Write(recordingMagic[:])
+	r.writer.WriteByte(recordingVersion)
+	return r
+}
+
+func (r *Recorder) Stream(frame scanner.Frame) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	now := time.Now()
+	if r.started.IsZero() {
+		r.started = now
+	}
+	offset := now.Sub(r.started).Microseconds()
+	data := appendUvarint(r.buffer[:0], uint64(offset-r.last))
+	r.last = offset
+	var timeBits [8]byte
+	binary.LittleEndian.PutUint64(timeBits[:], math.Float64bits(frame.Time))
+	data = append(data, timeBits[:]...)
+	data = appendPixels(data, r.previous, frame.Pixels)
+	r.buffer = data
+
+	_, r.err = r.writer.Write(data)
+	if r.err != nil {
+		streamingErrors.log(fmt.Errorf("recorder: %w", r.err))
+		return
+	}
+	r.previous = append(r.previous[:0], frame.Pixels...)
+}
+
+// appendPixels appends the pixel count and the runs of the pixels that changed compared to the previous ones
+func appendPixels(data []byte, previous, pixels []color.RGBA64) []byte {
+	data = appendUvarint(data, uint64(len(pixels)))
+	changed := func(i int) bool {
+		return len(previous) != len(pixels) || previous[i] != pixels[i]
+	}
+
+	for position := 0; position < len(pixels); {
+		unchanged := 0
+		for position+unchanged < len(pixels) && !changed(position+unchanged) {
+			unchanged++
+		}
+		position += unchanged
+		count := 0
+		for position+count < len(pixels) && changed(position+count) {
+			count++
+		}
+		data = appendUvarint(data, uint64(unchanged))
+		data = appendUvarint(data, uint64(count))
+		for _, pixel := range pixels[position : position+count] {
+			data = append(data,
+				byte(pixel.R), byte(pixel.R>>8),
+				byte(pixel.G), byte(pixel.G>>8),
+				byte(pixel.B), byte(pixel.B>>8),
+				byte(pixel.A), byte(pixel.A>>8),
+			)
+		}
+		position += count
+	}
+	return data
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	var buffer [binary.MaxVarintLen64]byte
+	return append(data, buffer[:binary.PutUvarint(buffer[:], value)]...)
+}
+
+// Flush writes all buffered frames to the destination and returns the first error that occurred while recording
+func (r *Recorder) Flush() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.writer.Flush()
+	return r.err
+}
+
+// RecordedFrame is a frame of a recording
+type RecordedFrame struct {
+	Offset time.Duration // the time since the first frame of the recording
+	scanner.Frame
+}
+
+// RecordingReader reads the frames of a recording that has been created by a Recorder
+type RecordingReader struct {
+	reader *bufio.Reader
+	offset time.Duration
+	pixels []color.RGBA64 // the pixels of the current frame
+}
+
+// NewRecordingReader creates a new RecordingReader and returns ErrInvalidRecording if the source doesn't contain
+// a recording.
+func NewRecordingReader(src io.Reader) (*RecordingReader, error) {
+	r := &RecordingReader{reader: bufio.NewReader(src)}
+	var header [5]byte
+	_, err := io.ReadFull(r.reader, header[:])
+	if err != nil {
+		return nil, ErrInvalidRecording
+	}
+	if [4]byte{header[0], header[1], header[2], header[3]} != recordingMagic || header[4] != recordingVersion {
+		return nil, ErrInvalidRecording
+	}
+	return r, nil
+}
+
+// Next returns the next frame of the recording or io.EOF after the last frame.
+// The pixels of the frame are reused and only valid until the next call.
+func (r *RecordingReader) Next() (RecordedFrame, error) {
+	delta, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		// a recording that ends between two frames is complete
+		return RecordedFrame{}, err
+	}
+	r.offset += time.Duration(delta) * time.Microsecond
+
+	var timeBits [8]byte
+	if _, err = io.ReadFull(r.reader, timeBits[:]); err != nil {
+		return RecordedFrame{}, truncated(err)
+	}
+	count, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return RecordedFrame{}, truncated(err)
+	}
+	if count > maxRecordedPixels {
+		return RecordedFrame{}, fmt.Errorf("recording: a frame with %d pixels exceeds the maximum of %d", count, maxRecordedPixels)
+	}
+	if int(count) != len(r.pixels) {
+		r.pixels = make([]color.RGBA64, count)
+	}
+
+	var pixel [8]byte
+	for position := 0; position < len(r.pixels); {
+		unchanged, err := binary.ReadUvarint(r.reader)
+		if err != nil {
+			return RecordedFrame{}, truncated(err)
+		}
+		changed, err := binary.ReadUvarint(r.reader)
+		if err != nil {
+			return RecordedFrame{}, truncated(err)
+		}
+		// the values are compared separately because their sum can overflow
+		remaining := uint64(len(r.pixels) - position)
+		if unchanged+changed == 0 || unchanged > remaining || changed > remaining-unchanged {
+			return RecordedFrame{}, fmt.Errorf("recording: invalid run in a frame with %d pixels", len(r.pixels))
+		}
+		position += int(unchanged)
+		for i := 0; i < int(changed); i++ {
+			if _, err = io.ReadFull(r.reader, pixel[:]); err != nil {
+				return RecordedFrame{}, truncated(err)
+			}
+			r.pixels[position] = color.RGBA64{
+				R: binary.LittleEndian.Uint16(pixel[0:]),
+				G: binary.LittleEndian.Uint16(pixel[2:]),
+				B: binary.LittleEndian.Uint16(pixel[4:]),
+				A: binary.LittleEndian.Uint16(pixel[6:]),
+			}
+			position++
+		}
+	}
+
+	return RecordedFrame{
+		Offset: r.offset,
+		Frame: scanner.Frame{
+			Time:   math.Float64frombits(binary.LittleEndian.Uint64(timeBits[:])),
+			Pixels: r.pixels,
+		},
+	}, nil
+}
+
+// truncated converts an EOF in the middle of a frame into an error
+func truncated(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Replay streams the frames of the recording to the streamer at the timing at which they have been recorded.
+// It returns after the last frame, when an error occurred or when stop has been closed.
+func Replay(src io.Reader, dst Streamer, stop <-chan struct{}) error {
+	reader, err := NewRecordingReader(src)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		frame, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if wait := time.Until(start.Add(frame.Offset)); wait > 0 {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				return nil
+			}
+		} else {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+		}
+		dst.Stream(frame.Frame)
+	}
+}
+
+// RecordingDiff is the result of DiffRecordings
+type RecordingDiff struct {
+	Frames        int     // the number of frames that exist in both recordings
+	ExtraFrames   int     // the number of frames that only exist in the longer recording
+	ChangedFrames int     // the number of frames that contain at least one different pixel
+	ChangedPixels int     // the total number of different pixels of all frames
+	MaxDifference uint16  // the largest difference of a single channel
+	FirstChange   int     // the index of the first changed frame or -1 if all frames are equal
+	FirstTime     float64 // the time of the first changed frame in the project
+}
+
+// Equal returns true if both recordings contain the same frames
+func (d RecordingDiff) Equal() bool {
+	return d.ChangedFrames == 0 && d.ExtraFrames == 0
+}
+
+// DiffRecordings compares two recordings frame by frame. The frames are compared by their index and not by their
+// timing, so recordings of the same project with a different scanner can be compared even if the scans took a
+// different amount of time. Frames with a different number of pixels count as changed in all pixels.
+func DiffRecordings(a, b io.Reader) (RecordingDiff, error) {
+	diff := RecordingDiff{FirstChange: -1}
+	readerA, err := NewRecordingReader(a)
+	if err != nil {
+		return diff, err
+	}
+	readerB, err := NewRecordingReader(b)
+	if err != nil {
+		return diff, err
+	}
+
+	for {
+		frameA, errA := readerA.Next()
+		frameB, errB := readerB.Next()
+		if errA != nil && errA != io.EOF {
+			return diff, errA
+		}
+		if errB != nil && errB != io.EOF {
+			return diff, errB
+		}
+		if errA == io.EOF || errB == io.EOF {
+			remaining := readerA
+			if errA == io.EOF {
+				remaining = readerB
+			}
+			if errA != errB {
+				diff.ExtraFrames++
+				if err := countFrames(remaining, &diff.ExtraFrames); err != nil {
+					return diff, err
+				}
+			}
+			return diff, nil
+		}
+
+		changed := comparePixels(frameA.Pixels, frameB.Pixels, &diff.MaxDifference)
+		if changed > 0 {
+			if diff.FirstChange < 0 {
+				diff.FirstChange = diff.Frames
+				diff.FirstTime = frameA.Time
+			}
+			diff.ChangedFrames++
+			diff.ChangedPixels += changed
+		}
+		diff.Frames++
+	}
+}
+
+// comparePixels returns the number of different pixels and raises maxDifference to the largest channel difference
+func comparePixels(a, b []color.RGBA64, maxDifference *uint16) int {
+	if len(a) != len(b) {
+		if len(a) > len(b) {
+			return len(a)
+		}
+		return len(b)
+	}
+	changed := 0
+	for i := range a {
+		if a[i] == b[i] {
+			continue
+		}
+		changed++
+		for _, difference := range [4]uint16{
+			channelDifference(a[i].R, b[i].R),
+			channelDifference(a[i].G, b[i].G),
+			channelDifference(a[i].B, b[i].B),
+			channelDifference(a[i].A, b[i].A),
+		} {
+			if difference > *maxDifference {
+				*maxDifference = difference
+			}
+		}
+	}
+	return changed
+}
+
+func channelDifference(a, b uint16) uint16 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// countFrames adds the number of remaining frames of the reader to count
+func countFrames(reader *RecordingReader, count *int) error {
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		*count++
+	}
+}
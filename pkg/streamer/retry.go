@@ -0,0 +1,45 @@
+package streamer
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	minRetryDelay = 100 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+// errWaitingForRetry is returned by writers whose connection failed until the next attempt is due.
+// The failure itself has already been reported, so it is neither logged again nor recorded as a new error.
+var errWaitingForRetry = errors.New("waiting for the next connection attempt")
+
+// retryBackoff decides when a failed connection should be attempted again.
+// The time between two attempts doubles after each failed attempt.
+type retryBackoff struct {
+	delay       time.Duration // the time to wait after the next failed attempt
+	nextAttempt time.Time     // no connection will be attempted before this time
+}
+
+// ready returns true if the next attempt may be made
+func (b *retryBackoff) ready() bool {
+	return !time.Now().Before(b.nextAttempt)
+}
+
+// failed sets the time of the next attempt and increases the delay for the one after that
+func (b *retryBackoff) failed() {
+	if b.delay < minRetryDelay {
+		b.delay = minRetryDelay
+	}
+	b.nextAttempt = time.Now().Add(b.delay)
+	b.delay *= 2
+	if b.delay > maxRetryDelay {
+		b.delay = maxRetryDelay
+	}
+}
+
+// succeeded resets the delay
+func (b *retryBackoff) succeeded() {
+	b.delay = minRetryDelay
+	b.nextAttempt = time.Time{}
+}
@@ -0,0 +1,13 @@
+package streamer
+
+import "fmt"
+
+// SerialBaudRates contains the baud rates that are commonly used by microcontrollers
+var SerialBaudRates = []int{9600, 19200, 38400, 57600, 115200, 230400, 460800, 500000, 921600, 1000000, 2000000}
+
+// ErrUnsupportedBaudRate is returned by OpenSerial if the baud rate can't be used on the current platform
+type ErrUnsupportedBaudRate int
+
+func (e ErrUnsupportedBaudRate) Error() string {
+	return fmt.Sprintf("unsupported baud rate %d", int(e))
+}
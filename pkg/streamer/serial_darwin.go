@@ -0,0 +1,18 @@
+package streamer
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
+
+func setSpeed(termios *unix.Termios, baudRate int) error {
+	if baudRate <= 0 {
+		return ErrUnsupportedBaudRate(baudRate)
+	}
+	// macOS accepts the baud rate directly
+	termios.Ispeed = uint64(baudRate)
+	termios.Ospeed = uint64(baudRate)
+	return nil
+}
@@ -0,0 +1,34 @@
+package streamer
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
+
+var linuxBaudRates = map[int]uint32{
+	9600:    unix.B9600,
+	19200:   unix.B19200,
+	38400:   unix.B38400,
+	57600:   unix.B57600,
+	115200:  unix.B115200,
+	230400:  unix.B230400,
+	460800:  unix.B460800,
+	500000:  unix.B500000,
+	921600:  unix.B921600,
+	1000000: unix.B1000000,
+	2000000: unix.B2000000,
+}
+
+func setSpeed(termios *unix.Termios, baudRate int) error {
+	speed, ok := linuxBaudRates[baudRate]
+	if !ok {
+		return ErrUnsupportedBaudRate(baudRate)
+	}
+	termios.Cflag &^= unix.CBAUD
+	termios.Cflag |= speed
+	termios.Ispeed = speed
+	termios.Ospeed = speed
+	return nil
+}
@@ -0,0 +1,128 @@
+package streamer
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+	"golang.org/x/sys/unix"
+)
+
+// openPseudoTerminal opens a pseudo terminal and returns its master side together with the path of the device
+// that acts as the serial device. The test is skipped if the system doesn't provide pseudo terminals.
+func openPseudoTerminal(t *testing.T) (*os.File, string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	err = unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0)
+	if err != nil {
+		t.Skipf("pseudo terminal could not be unlocked: %v", err)
+	}
+	number, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("pseudo terminal has no device: %v", err)
+	}
+	path := fmt.Sprintf("/dev/pts/%d", number)
+	if _, err := os.Stat(path); err != nil {
+		t.Skipf("pseudo terminal device is not available: %v", err)
+	}
+	return master, path
+}
+
+// streamToPseudoTerminal streams the frame over a pseudo terminal and returns the bytes that arrived
+func streamToPseudoTerminal(t *testing.T, protocol Protocol, frame scanner.Frame, length int) []byte {
+	t.Helper()
+	master, path := openPseudoTerminal(t)
+	config := DefaultOutputConfig()
+	config.Protocol = protocol
+	config.SerialDevice = path
+	str, err := config.NewStreamer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, closer, err := config.OpenDestination()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	str.SetDestination(writer)
+	str.Stream(frame)
+
+	data := make([]byte, length)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(master, data)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the frame didn't arrive")
+	}
+	return data
+}
+
+// testFrame returns a frame whose pixels are either fully on or off in every channel, so the result doesn't
+// depend on the gamma
+func testFrame(size int) scanner.Frame {
+	pixels := make([]color.RGBA64, size)
+	for i := range pixels {
+		if i%2 == 0 {
+			pixels[i].R = 0xffff
+		}
+		if i%3 == 0 {
+			pixels[i].G = 0xffff
+		}
+		pixels[i].B = 0xffff
+		pixels[i].A = 0xffff
+	}
+	return scanner.Frame{Pixels: pixels}
+}
+
+func expectedChannels(frame scanner.Frame) []byte {
+	var data []byte
+	for _, pixel := range frame.Pixels {
+		data = append(data, byte(pixel.R>>8), byte(pixel.G>>8), byte(pixel.B>>8))
+	}
+	return data
+}
+
+func TestAdalightFraming(t *testing.T) {
+	frame := testFrame(300)
+	data := streamToPseudoTerminal(t, ProtocolAdalight, frame, adalightHeaderLength+3*300)
+
+	// 299 = 0x012b pixels follow after the first one
+	header := []byte{'A', 'd', 'a', 0x01, 0x2b, 0x01 ^ 0x2b ^ 0x55}
+	if string(data[:adalightHeaderLength]) != string(header) {
+		t.Errorf("header is %x instead of %x", data[:adalightHeaderLength], header)
+	}
+	if string(data[adalightHeaderLength:]) != string(expectedChannels(frame)) {
+		t.Error("the pixels don't match the frame")
+	}
+}
+
+func TestTPM2Framing(t *testing.T) {
+	frame := testFrame(100)
+	data := streamToPseudoTerminal(t, ProtocolTPM2, frame, tpm2HeaderLength+3*100+1)
+
+	header := []byte{tpm2StartByte, tpm2DataFrame, 0x01, 0x2c} // 300 bytes of data
+	if string(data[:tpm2HeaderLength]) != string(header) {
+		t.Errorf("header is %x instead of %x", data[:tpm2HeaderLength], header)
+	}
+	if string(data[tpm2HeaderLength:len(data)-1]) != string(expectedChannels(frame)) {
+		t.Error("the pixels don't match the frame")
+	}
+	if data[len(data)-1] != tpm2EndByte {
+		t.Errorf("frame ends with %#x instead of %#x", data[len(data)-1], tpm2EndByte)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package streamer
+
+import (
+	"errors"
+	"io"
+)
+
+// OpenSerial opens the serial device at the path and configures it to transfer raw bytes with the baud rate.
+// Serial devices are currently only supported on linux and macOS.
+func OpenSerial(path string, baudRate int) (io.WriteCloser, error) {
+	return nil, errors.New("serial devices are not supported on this platform")
+}
@@ -0,0 +1,49 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package streamer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// OpenSerial opens the serial device at the path and configures it to transfer raw bytes with the baud rate.
+func OpenSerial(path string, baudRate int) (io.WriteCloser, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = configureSerial(int(file.Fd()), baudRate)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("serial device %q could not be configured: %w", path, err)
+	}
+	return file, nil
+}
+
+// configureSerial puts the terminal into raw mode with 8 data bits, no parity and one stop bit
+func configureSerial(fd int, baudRate int) error {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return err
+	}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB
+	termios.Cflag |= unix.CS8 | unix.CLOCAL | unix.CREAD
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	err = setSpeed(termios, baudRate)
+	if err != nil {
+		return err
+	}
+	return unix.IoctlSetTermios(fd, ioctlSetTermios, termios)
+}
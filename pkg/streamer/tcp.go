@@ -0,0 +1,77 @@
+package streamer
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	tcpDialTimeout  = time.Second
+	tcpWriteTimeout = time.Second
+)
+
+// TCPWriter keeps a persistent TCP connection to the address.
+// If the connection can't be established or breaks it is reestablished automatically. The time between
+// two attempts doubles after each failed attempt. Data that is written while waiting for the next attempt is
+// dropped with errWaitingForRetry as streamers only care about the latest frame anyway.
+type TCPWriter struct {
+	address string
+	conn    net.Conn
+	retry   retryBackoff
+	closed  bool
+	mutex   sync.Mutex
+}
+
+// NewTCPWriter creates a new TCPWriter. The connection is established when data is written for the first time.
+func NewTCPWriter(address string) *TCPWriter {
+	return &TCPWriter{
+		address: address,
+	}
+}
+
+func (w *TCPWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return 0, fmt.Errorf("tcp connection to %s has been closed", w.address)
+	}
+
+	if w.conn == nil {
+		if !w.retry.ready() {
+			return 0, fmt.Errorf("tcp connection to %s: %w", w.address, errWaitingForRetry)
+		}
+		conn, err := net.DialTimeout("tcp", w.address, tcpDialTimeout)
+		if err != nil {
+			w.retry.failed()
+			return 0, err
+		}
+		w.conn = conn
+		w.retry.succeeded()
+	}
+
+	// a server that stopped reading must not block the streamer forever
+	w.conn.SetWriteDeadline(time.Now().Add(tcpWriteTimeout))
+	n, err := w.conn.Write(data)
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.retry.failed()
+		return n, err
+	}
+	return n, nil
+}
+
+// Close closes the connection. After calling Close all writes will fail.
+func (w *TCPWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,35 @@
+package streamer
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestTCPWriterWaitingForRetry(t *testing.T) {
+	// reserve a port and close it again so that nothing is listening
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	var health outputHealth
+	writer := healthWriter{destination: NewTCPWriter(address), health: &health}
+	if _, err = writer.Write([]byte{1}); err == nil || errors.Is(err, errWaitingForRetry) {
+		t.Fatalf("the first write returned %v instead of the dial error", err)
+	}
+	dialErr := health.get().LastError
+	if _, err = writer.Write([]byte{2}); !errors.Is(err, errWaitingForRetry) {
+		t.Fatalf("the write during the backoff returned %v instead of errWaitingForRetry", err)
+	}
+
+	result := health.get()
+	if result.Connected || result.PacketsSent != 0 || result.PacketsFailed != 2 {
+		t.Errorf("the health %+v counts a dropped packet as sent", result)
+	}
+	if result.LastError != dialErr {
+		t.Errorf("the last error %v has replaced the dial error %v", result.LastError, dialErr)
+	}
+}
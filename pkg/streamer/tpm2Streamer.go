@@ -0,0 +1,80 @@
+package streamer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/omniskop/firefly/pkg/scanner"
+)
+
+const (
+	tpm2StartByte     = 0xc9
+	tpm2DataFrame     = 0xda
+	tpm2EndByte       = 0x36
+	tpm2HeaderLength  = 4
+	tpm2MaxDataLength = 0xffff
+)
+
+// TPM2Streamer sends frames as TPM2 data frames. TPM2 is supported by many firmwares for microcontrollers that
+// are connected over USB.
+type TPM2Streamer struct {
+	destination io.Writer
+	calibrated
+	buffer []byte // reused between frames
+	mutex  sync.Mutex
+}
+
+// NewTPM2 creates a new TPM2 streamer that writes to the destination which is usually a serial device.
+// The firmware writes the values directly to the LEDs so the streamer performs the gamma correction.
+func NewTPM2(dst io.Writer) *TPM2Streamer {
+	return &TPM2Streamer{
+		destination: dst,
+		calibrated:  calibrated{defaultGamma: ProtocolTPM2.DefaultGamma()},
+	}
+}
+
+func (s *TPM2Streamer) SetDestination(writer io.Writer) {
+	s.mutex.Lock()
+	s.destination = writer
+	s.mutex.Unlock()
+}
+
+// maxPixels returns the number of pixels that fit into a single frame with the current calibration.
+// The length of the data is a 16 bit value so larger frames can't be sent.
+func (s *TPM2Streamer) maxPixels() int {
+	return tpm2MaxDataLength / s.calibrator().channels()
+}
+
+func (s *TPM2Streamer) Stream(frame scanner.Frame) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.destination == nil {
+		return
+	}
+
+	cal := s.calibrator()
+	pixels := frame.Pixels
+	if len(pixels) > s.maxPixels() {
+		// sending only a part of the pixels would leave the rest of the LEDs in an outdated state
+		streamingErrors.log(fmt.Errorf("%d pixels don't fit into a single TPM2 frame of at most %d pixels", len(pixels), s.maxPixels()))
+		return
+	}
+
+	size := cal.channels() * len(pixels)
+	packet := resizeBuffer(s.buffer, tpm2HeaderLength+size+1)
+	s.buffer = packet
+	packet[0] = tpm2StartByte
+	packet[1] = tpm2DataFrame
+	packet[2] = byte(size >> 8)
+	packet[3] = byte(size)
+	data := packet[tpm2HeaderLength:]
+	for _, pixel := range pixels {
+		data = data[cal.write(data, pixel):]
+	}
+	packet[len(packet)-1] = tpm2EndByte
+	_, err := s.destination.Write(packet)
+	if err != nil {
+		streamingErrors.log(err)
+	}
+}
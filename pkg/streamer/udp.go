@@ -1,20 +1,95 @@
 package streamer
 
-import "net"
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
 
+// udpResolveInterval is the time after which the address of a UDPWriter is resolved again.
+// That way the writer follows changes of the DNS entry or of the network interface that leads to the device.
+const udpResolveInterval = 30 * time.Second
+
+// UDPWriter sends every write as a single datagram to the address.
+// If sending fails the socket is recreated, which resolves the address again. Until the next attempt all writes fail.
 type UDPWriter struct {
-	net.Conn
-	address string
+	address  string
+	conn     net.Conn
+	resolved time.Time // the time at which the address has been resolved for conn
+	retry    retryBackoff
+	closed   bool
+	mutex    sync.Mutex
+}
+
+// NewUDPWriter creates a UDPWriter and returns an error if the address can't be resolved
+func NewUDPWriter(address string) (*UDPWriter, error) {
+	w := &UDPWriter{address: address}
+	err := w.dial()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// dial resolves the address and replaces the socket of the writer
+func (w *UDPWriter) dial() error {
+	conn, err := net.Dial("udp", w.address)
+	if err != nil {
+		return err
+	}
+	if w.conn != nil {
+		w.conn.Close()
+	}
+	w.conn = conn
+	w.resolved = time.Now()
+	return nil
 }
 
-func NewUDPWriter(address string) (UDPWriter, error) {
-	conn, err := net.Dial("udp", address)
+func (w *UDPWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return 0, fmt.Errorf("udp connection to %s has been closed", w.address)
+	}
+
+	if w.conn == nil {
+		if !w.retry.ready() {
+			return 0, fmt.Errorf("udp connection to %s: %w", w.address, errWaitingForRetry)
+		}
+		err := w.dial()
+		if err != nil {
+			w.retry.failed()
+			return 0, err
+		}
+		w.retry.succeeded()
+	} else if time.Since(w.resolved) > udpResolveInterval {
+		err := w.dial()
+		if err != nil {
+			// the previous socket is kept until the address can be resolved again
+			w.resolved = time.Now()
+		}
+	}
+
+	n, err := w.conn.Write(data)
 	if err != nil {
-		return UDPWriter{}, nil
+		w.conn.Close()
+		w.conn = nil
+		w.retry.failed()
+		return n, err
 	}
+	return n, nil
+}
 
-	return UDPWriter{
-		Conn:    conn,
-		address: address,
-	}, nil
+// Close closes the socket. After calling Close all writes will fail.
+func (w *UDPWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
 }
@@ -1,17 +1,32 @@
 package streamer
 
 import (
+	"encoding/binary"
+	"image/color"
 	"io"
 	"sync"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/omniskop/firefly/pkg/scanner"
 )
 
+const (
+	wledProtocolDRGB  = 2
+	wledProtocolDRGBW = 3
+	wledProtocolDNRGB = 4
+
+	wledMaxDRGBPixels  = 490 // the maximum number of pixels in a DRGB packet
+	wledMaxDRGBWPixels = 367 // the maximum number of pixels in a DRGBW packet
+	wledMaxDNRGBPixels = 489 // the maximum number of pixels in a DNRGB packet
+)
+
 type WLEDStreamer struct {
 	destination io.Writer
-	mutex       sync.Mutex
+	calibrated
+	// Timeout is the number of seconds after which WLED returns to its normal mode when no more frames arrive.
+	// 255 keeps the last frame until told otherwise.
+	Timeout byte
+	buffer  []byte // reused between packets
+	mutex   sync.Mutex
 }
 
 // NewWLED creates a new Streamer that can control a WLED Device.
@@ -19,32 +34,83 @@ type WLEDStreamer struct {
 func NewWLED(dst io.Writer) *WLEDStreamer {
 	return &WLEDStreamer{
 		destination: dst,
+		calibrated:  calibrated{defaultGamma: ProtocolWLED.DefaultGamma()},
+		Timeout:     255,
 	}
 }
 
+// Stream sends the frame to WLED. Frames with up to 490 pixels are sent in a single DRGB packet,
+// longer frames are split into multiple DNRGB packets that each contain their start index.
+// If the calibration contains a white channel and the frame has up to 367 pixels a DRGBW packet is used instead.
+// Longer frames can't contain a white channel.
 func (s *WLEDStreamer) Stream(frame scanner.Frame) {
-	// Currently only the DRGB protocol is used.
-	// In the future it could be expanded to add support for more wled protocols.
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if s.destination == nil {
 		return
 	}
+	cal := s.calibrator()
+
+	if cal.white && len(frame.Pixels) <= wledMaxDRGBWPixels {
+		s.write(s.buildDRGBPacket(cal, wledProtocolDRGBW, frame.Pixels))
+		return
+	}
+	if len(frame.Pixels) <= wledMaxDRGBPixels {
+		s.write(s.buildDRGBPacket(cal, wledProtocolDRGB, frame.Pixels))
+		return
+	}
 
-	var packet = make([]byte, 2+3*len(frame.Pixels))
-	packet[0] = 2   // 2 = DRGB protocol
-	packet[1] = 255 // 255 = keep this frame until told otherwise
-	for i, pixel := range frame.Pixels {
-		r, g, b, _ := pixel.RGBA()
-		// map from 0xffff to 0xff
-		packet[2+i*3+0] = byte(float64(r) / 0xffff * 0xff)
-		packet[2+i*3+1] = byte(float64(g) / 0xffff * 0xff)
-		packet[2+i*3+2] = byte(float64(b) / 0xffff * 0xff)
+	for start := 0; start < len(frame.Pixels); start += wledMaxDNRGBPixels {
+		end := start + wledMaxDNRGBPixels
+		if end > len(frame.Pixels) {
+			end = len(frame.Pixels)
+		}
+		s.write(s.buildDNRGBPacket(cal, start, frame.Pixels[start:end]))
 	}
+}
+
+func (s *WLEDStreamer) write(packet []byte) {
 	_, err := s.destination.Write(packet)
 	if err != nil {
-		logrus.Errorf("streaming error: %v", err)
+		streamingErrors.log(err)
+	}
+}
+
+// buildDRGBPacket creates a DRGB or DRGBW packet that contains the pixels.
+// The returned slice is only valid until the next call.
+func (s *WLEDStreamer) buildDRGBPacket(cal *calibrator, protocol byte, pixels []color.RGBA64) []byte {
+	channels := 3
+	if protocol == wledProtocolDRGBW {
+		channels = 4
+	}
+	packet := resizeBuffer(s.buffer, 2+channels*len(pixels))
+	s.buffer = packet
+	packet[0] = protocol
+	packet[1] = s.Timeout
+	data := packet[2:]
+	for _, pixel := range pixels {
+		if channels == 4 {
+			data = data[cal.write(data, pixel):]
+		} else {
+			data = data[cal.writeRGB(data, pixel):]
+		}
+	}
+	return packet
+}
+
+// buildDNRGBPacket creates a DNRGB packet that contains the pixels beginning at the start index.
+// The returned slice is only valid until the next call.
+func (s *WLEDStreamer) buildDNRGBPacket(cal *calibrator, start int, pixels []color.RGBA64) []byte {
+	packet := resizeBuffer(s.buffer, 4+3*len(pixels))
+	s.buffer = packet
+	packet[0] = wledProtocolDNRGB
+	packet[1] = s.Timeout
+	binary.BigEndian.PutUint16(packet[2:], uint16(start))
+	data := packet[4:]
+	for _, pixel := range pixels {
+		data = data[cal.writeRGB(data, pixel):]
 	}
+	return packet
 }
 
 func (s *WLEDStreamer) SetDestination(writer io.Writer) {